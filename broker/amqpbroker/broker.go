@@ -0,0 +1,132 @@
+package amqpbroker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"mission_control/broker"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Broker is the RabbitMQ broker.Broker: a reconnecting connection that
+// publishes in confirm mode and gives every consumer a channel of its own
+type Broker struct {
+	conn      *Connection
+	publisher *Publisher
+}
+
+var _ broker.Broker = (*Broker)(nil)
+
+// NewBroker connects to url in the background. Publishes wait up to
+// confirmTimeout for the broker's confirmation.
+func NewBroker(url string, confirmTimeout time.Duration) *Broker {
+	publisher := NewPublisher(confirmTimeout)
+	return &Broker{conn: Connect(url, publisher.Attach), publisher: publisher}
+}
+
+// Declare declares the topology now if connected and again after every reconnect
+func (b *Broker) Declare(t broker.Topology) error {
+	return b.conn.Setup(func(ch *amqp.Channel) error { return declare(ch, t) })
+}
+
+// Publish publishes msg and waits for the broker to confirm it
+func (b *Broker) Publish(exchange, key string, mandatory bool, msg amqp.Publishing) error {
+	return b.publisher.Publish(exchange, key, mandatory, msg)
+}
+
+// Consume declares the consumer's topology and consumes its queues on a
+// channel of its own, starting again after reconnects
+func (b *Broker) Consume(ctx context.Context, c broker.Consumer) <-chan amqp.Delivery {
+	return b.conn.Consume(ctx, func(ch *amqp.Channel) (<-chan amqp.Delivery, error) {
+		if err := declare(ch, c.Declare); err != nil {
+			return nil, err
+		}
+		if c.Prefetch > 0 {
+			// global: the limit is shared by the consumers of every queue on the channel
+			if err := ch.Qos(c.Prefetch, 0, true); err != nil {
+				return nil, err
+			}
+		}
+		return consume(ctx, ch, c.Queues, c.AutoAck)
+	})
+}
+
+// Close closes the connection
+func (b *Broker) Close() error {
+	return b.conn.Close()
+}
+
+// declare declares the durable exchanges, the queues and the bindings of t on ch
+func declare(ch *amqp.Channel, t broker.Topology) error {
+	for _, e := range t.Exchanges {
+		if err := ch.ExchangeDeclare(e.Name, e.Kind, true, false, false, false, nil); err != nil {
+			return err
+		}
+	}
+	for _, q := range t.Queues {
+		if _, err := ch.QueueDeclare(q.Name, q.Durable, q.AutoDelete, q.Exclusive, false, q.Args); err != nil {
+			return err
+		}
+	}
+	for _, bind := range t.Bindings {
+		if err := ch.QueueBind(bind.Queue, bind.Key, bind.Exchange, false, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consume consumes every queue on ch and merges the deliveries into one
+// channel. Once ctx is done the consumers are cancelled, so the broker stops
+// sending deliveries while the channel stays open for acknowledgements, and
+// the merged channel closes when the last of them has stopped.
+func consume(ctx context.Context, ch *amqp.Channel, queues []string, autoAck bool) (<-chan amqp.Delivery, error) {
+	var deliveries []<-chan amqp.Delivery
+	for _, queue := range queues {
+		// The queue name is unique among the consumers of the channel
+		msgs, err := ch.Consume(queue, queue, autoAck, false, false, false, nil)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, msgs)
+	}
+	closed := ch.NotifyClose(make(chan *amqp.Error, 1))
+	go func() {
+		select {
+		case <-ctx.Done():
+			for _, queue := range queues {
+				ch.Cancel(queue, false)
+			}
+		case <-closed:
+		}
+	}()
+	return merge(ctx, deliveries, autoAck), nil
+}
+
+// merge forwards deliveries into one channel, which is closed once every one
+// of them is. Deliveries the broker sent before it received the cancellation
+// are requeued once ctx is done instead of being handed out.
+func merge(ctx context.Context, deliveries []<-chan amqp.Delivery, autoAck bool) <-chan amqp.Delivery {
+	merged := make(chan amqp.Delivery)
+	var wg sync.WaitGroup
+	for _, msgs := range deliveries {
+		wg.Go(func() {
+			for d := range msgs {
+				select {
+				case merged <- d:
+				case <-ctx.Done():
+					if !autoAck {
+						d.Nack(false, true)
+					}
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(merged)
+	}()
+	return merged
+}
@@ -0,0 +1,76 @@
+package amqpbroker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// requeues records the requeue flag of every nacked delivery
+type requeues chan bool
+
+func (r requeues) Ack(tag uint64, multiple bool) error { return nil }
+
+func (r requeues) Nack(tag uint64, multiple, requeue bool) error {
+	r <- requeue
+	return nil
+}
+
+func (r requeues) Reject(tag uint64, requeue bool) error { return r.Nack(tag, false, requeue) }
+
+func TestBroker_ConsumeClosesWhenIdle(t *testing.T) {
+	b := NewBroker(unreachableURL, time.Second)
+	defer b.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs := b.Consume(ctx, broker.Consumer{Queues: []string{"orders"}})
+	cancel()
+	select {
+	case _, ok := <-msgs:
+		if ok {
+			t.Fatal("expected no deliveries")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the deliveries to close once ctx is done")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	orders, status := make(chan amqp.Delivery, 1), make(chan amqp.Delivery, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	merged := merge(ctx, []<-chan amqp.Delivery{orders, status}, false)
+
+	nacks := make(requeues, 1)
+	orders <- amqp.Delivery{DeliveryTag: 1, Acknowledger: nacks}
+	if d := <-merged; d.DeliveryTag != 1 {
+		t.Fatalf("expected the delivery to be forwarded, got %+v", d)
+	}
+
+	// A delivery sent before the consumer was cancelled is requeued
+	cancel()
+	status <- amqp.Delivery{DeliveryTag: 2, Acknowledger: nacks}
+	select {
+	case requeue := <-nacks:
+		if !requeue {
+			t.Fatal("expected the delivery to be requeued")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the delivery to be nacked")
+	}
+
+	// The merged channel closes once the broker has cancelled every consumer
+	close(orders)
+	close(status)
+	select {
+	case _, ok := <-merged:
+		if ok {
+			t.Fatal("expected no further deliveries")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the merged deliveries to close")
+	}
+}
@@ -0,0 +1,254 @@
+package amqpbroker
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Reconnect backoff bounds
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// ErrConnectionClosed is returned once the connection has been closed for good
+var ErrConnectionClosed = errors.New("rabbitmq connection closed")
+
+// Connection keeps one AMQP connection and channel open. Whenever the broker
+// closes either, it re-dials with exponential backoff and runs its setup hooks
+// (declaring topology, enabling confirms, ...) on the new channel before
+// handing it out again. Consumers get channels of their own.
+type Connection struct {
+	url string
+
+	mu      sync.Mutex
+	hooks   []func(ch *amqp.Channel) error
+	conn    *amqp.Connection
+	ch      *amqp.Channel // nil while reconnecting
+	changed chan struct{} // closed and replaced whenever ch changes
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// Connect starts connecting to url in the background and keeps the
+// connection open until Close. hooks run in order on every new channel.
+func Connect(url string, hooks ...func(ch *amqp.Channel) error) *Connection {
+	c := &Connection{
+		url:     url,
+		hooks:   hooks,
+		changed: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// run dials, waits for the connection or channel to close and dials again
+func (c *Connection) run() {
+	delay := minReconnectDelay
+	for {
+		conn, ch, err := c.open()
+		if err != nil {
+			log.Printf("RabbitMQ connection failed: %v — retrying in %v", err, delay)
+			select {
+			case <-time.After(delay):
+			case <-c.done:
+				return
+			}
+			delay = min(delay*2, maxReconnectDelay)
+			continue
+		}
+		delay = minReconnectDelay
+		log.Println("Connected to RabbitMQ")
+
+		connClosed := conn.NotifyClose(make(chan *amqp.Error, 1))
+		chClosed := ch.NotifyClose(make(chan *amqp.Error, 1))
+		select {
+		case err := <-connClosed:
+			log.Printf("RabbitMQ connection lost: %v", err)
+		case err := <-chClosed:
+			log.Printf("RabbitMQ channel lost: %v", err)
+		case <-c.done:
+		}
+		c.set(nil, nil)
+		conn.Close()
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+	}
+}
+
+// open dials the broker, opens a channel, runs the hooks on it and makes it
+// the current channel. Hooks run under the lock so Setup cannot miss a channel.
+func (c *Connection) open() (*amqp.Connection, *amqp.Channel, error) {
+	conn, err := amqp.Dial(c.url)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, hook := range c.hooks {
+		if err := hook(ch); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+	}
+	c.setLocked(conn, ch)
+	return conn, ch, nil
+}
+
+// Setup adds a hook that runs on every new channel and runs it on the open
+// channel right away, returning its error. While the connection is down the
+// hook first runs once it is back.
+func (c *Connection) Setup(hook func(ch *amqp.Channel) error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.hooks = append(c.hooks, hook)
+	if c.ch == nil {
+		return nil
+	}
+	return hook(c.ch)
+}
+
+// set publishes the current connection and channel and wakes up waiters
+func (c *Connection) set(conn *amqp.Connection, ch *amqp.Channel) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.setLocked(conn, ch)
+}
+
+// setLocked is set with c.mu held
+func (c *Connection) setLocked(conn *amqp.Connection, ch *amqp.Channel) {
+	c.conn, c.ch = conn, ch
+	close(c.changed)
+	c.changed = make(chan struct{})
+}
+
+// current returns the connection and channel (nil while reconnecting) and a
+// channel that is closed when they change
+func (c *Connection) current() (*amqp.Connection, *amqp.Channel, <-chan struct{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.conn, c.ch, c.changed
+}
+
+// Channel returns the open channel, waiting while the connection is down
+func (c *Connection) Channel(ctx context.Context) (*amqp.Channel, error) {
+	_, ch, err := c.wait(ctx)
+	return ch, err
+}
+
+// wait returns the open connection and channel, waiting while the connection is down
+func (c *Connection) wait(ctx context.Context) (*amqp.Connection, *amqp.Channel, error) {
+	for {
+		conn, ch, changed := c.current()
+		if ch != nil {
+			return conn, ch, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-c.done:
+			return nil, nil, ErrConnectionClosed
+		}
+	}
+}
+
+// Consume starts a consumer with start on a channel of its own and forwards
+// its deliveries to the returned channel. When the deliveries stop - the
+// connection was lost or the channel failed - the consumer is started again
+// on a new channel, so consumers survive reconnects. The returned channel is
+// closed once ctx is done or the connection is closed. Deliveries received
+// before a restart can no longer be acknowledged; the broker redelivers them.
+func (c *Connection) Consume(ctx context.Context, start func(ch *amqp.Channel) (<-chan amqp.Delivery, error)) <-chan amqp.Delivery {
+	out := make(chan amqp.Delivery)
+	go func() {
+		defer close(out)
+		for {
+			conn, _, err := c.wait(ctx)
+			if err != nil {
+				return
+			}
+			if err := c.consume(ctx, conn, start, out); err != nil {
+				log.Printf("Consumer stopped: %v — restarting in %v", err, minReconnectDelay)
+			}
+			select {
+			case <-time.After(minReconnectDelay):
+			case <-ctx.Done():
+				return
+			case <-c.done:
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// consume forwards the deliveries of one consumer channel until they stop or
+// ctx is done. The channel stays open once ctx is done, so deliveries that are
+// still being handled can be acknowledged; it closes with the connection.
+func (c *Connection) consume(ctx context.Context, conn *amqp.Connection, start func(ch *amqp.Channel) (<-chan amqp.Delivery, error), out chan<- amqp.Delivery) error {
+	ch, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	msgs, err := start(ch)
+	if err != nil {
+		ch.Close()
+		return err
+	}
+	if forward(ctx, msgs, out) {
+		return nil
+	}
+	ch.Close()
+	return errors.New("deliveries stopped")
+}
+
+// forward passes deliveries from msgs to out until msgs is closed or ctx is
+// done, even while no delivery arrives. It reports whether ctx is done.
+func forward(ctx context.Context, msgs <-chan amqp.Delivery, out chan<- amqp.Delivery) bool {
+	for {
+		select {
+		case d, ok := <-msgs:
+			if !ok {
+				return false
+			}
+			select {
+			case out <- d:
+			case <-ctx.Done():
+				return true
+			}
+		case <-ctx.Done():
+			return true
+		}
+	}
+}
+
+// Close closes the connection and stops reconnecting
+func (c *Connection) Close() error {
+	c.closeOnce.Do(func() { close(c.done) })
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		return c.conn.Close()
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package amqpbroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"mission_control/broker"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrNotConfirmed is returned when the broker nacked a message or did not confirm it in time
+var ErrNotConfirmed = errors.New("message not confirmed by the broker")
+
+// Publisher publishes in confirm mode on the channel of a Connection. Publish
+// only returns once the broker has confirmed the message, so a nil error means
+// the message is in a queue rather than merely written to the connection.
+type Publisher struct {
+	timeout time.Duration
+	seq     atomic.Uint64 // source of message IDs used to match returns
+
+	mu       sync.Mutex
+	returned map[string]bool // mandatory messages awaiting confirmation, true once returned
+	current  *confirmChannel // nil until the first channel is attached
+	attached chan struct{}   // closed and replaced whenever a channel is attached
+}
+
+// confirmChannel is a channel in confirm mode whose returns are being handled
+type confirmChannel struct {
+	ch      *amqp.Channel
+	barrier chan struct{} // handleReturns receives from it between returns
+	closed  chan struct{} // closed when the channel's returns stop
+}
+
+// NewPublisher returns a publisher that waits up to timeout for each message
+// to be confirmed. Pass its Attach to Connect so it publishes on every new channel.
+func NewPublisher(timeout time.Duration) *Publisher {
+	return &Publisher{
+		timeout:  timeout,
+		returned: make(map[string]bool),
+		attached: make(chan struct{}),
+	}
+}
+
+// newConfirmChannel returns a confirm channel whose returns still need to be handled
+func newConfirmChannel(ch *amqp.Channel) *confirmChannel {
+	return &confirmChannel{
+		ch:      ch,
+		barrier: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Attach puts ch into confirm mode and publishes on it from now on
+func (p *Publisher) Attach(ch *amqp.Channel) error {
+	if err := ch.Confirm(false); err != nil {
+		return err
+	}
+	cc := newConfirmChannel(ch)
+	go p.handleReturns(cc, ch.NotifyReturn(make(chan amqp.Return)))
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.current = cc
+	close(p.attached)
+	p.attached = make(chan struct{})
+	return nil
+}
+
+// channel returns the attached channel, waiting for the next one while the
+// current channel is closed
+func (p *Publisher) channel(ctx context.Context) (*confirmChannel, error) {
+	for {
+		p.mu.Lock()
+		cc, attached := p.current, p.attached
+		p.mu.Unlock()
+		if cc != nil {
+			select {
+			case <-cc.closed:
+			default:
+				return cc, nil
+			}
+		}
+		select {
+		case <-attached:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: no open channel within %v", ErrNotConfirmed, p.timeout)
+		}
+	}
+}
+
+// Publish sends msg and waits for the broker to confirm it. The message ID is
+// replaced by one the publisher uses to recognise returns. Mandatory messages
+// that cannot be routed to any queue fail with broker.ErrUnroutable.
+func (p *Publisher) Publish(exchange, key string, mandatory bool, msg amqp.Publishing) error {
+	id := strconv.FormatUint(p.seq.Add(1), 10)
+	msg.MessageId = id
+	if mandatory {
+		p.mu.Lock()
+		p.returned[id] = false
+		p.mu.Unlock()
+		defer func() {
+			p.mu.Lock()
+			delete(p.returned, id)
+			p.mu.Unlock()
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	cc, err := p.channel(ctx)
+	if err != nil {
+		return err
+	}
+	confirmation, err := cc.ch.PublishWithDeferredConfirmWithContext(ctx, exchange, key, mandatory, false, msg)
+	if err != nil {
+		return err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return fmt.Errorf("%w within %v", ErrNotConfirmed, p.timeout)
+	}
+	if !acked {
+		return fmt.Errorf("%w: nacked", ErrNotConfirmed)
+	}
+	if !mandatory {
+		return nil
+	}
+	// The broker sends a return before the ack of the same message; make sure
+	// handleReturns has recorded it
+	select {
+	case cc.barrier <- struct{}{}:
+	case <-cc.closed:
+	case <-ctx.Done():
+		return fmt.Errorf("%w within %v", ErrNotConfirmed, p.timeout)
+	}
+	p.mu.Lock()
+	returned := p.returned[id]
+	p.mu.Unlock()
+	if returned {
+		return fmt.Errorf("%w: exchange %q, routing key %s", broker.ErrUnroutable, exchange, key)
+	}
+	return nil
+}
+
+// handleReturns marks messages returned on cc until it closes. Returns are
+// handled one at a time, so a send on barrier succeeds only after every
+// return received before it has been recorded.
+func (p *Publisher) handleReturns(cc *confirmChannel, returns <-chan amqp.Return) {
+	defer close(cc.closed)
+	for {
+		select {
+		case r, ok := <-returns:
+			if !ok {
+				return
+			}
+			log.Printf("Broker returned message to %q with routing key %s: %s", r.Exchange, r.RoutingKey, r.ReplyText)
+			p.mu.Lock()
+			if _, pending := p.returned[r.MessageId]; pending {
+				p.returned[r.MessageId] = true
+			}
+			p.mu.Unlock()
+		case <-cc.barrier:
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package amqpbroker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestPublisher_HandleReturns(t *testing.T) {
+	p := NewPublisher(time.Second)
+	cc := newConfirmChannel(nil)
+	returns := make(chan amqp.Return)
+	go p.handleReturns(cc, returns)
+
+	p.mu.Lock()
+	p.returned["1"], p.returned["2"] = false, false
+	p.mu.Unlock()
+
+	returns <- amqp.Return{MessageId: "1", ReplyText: "NO_ROUTE"}
+	returns <- amqp.Return{MessageId: "unknown", ReplyText: "NO_ROUTE"}
+	// Once the barrier is passed every earlier return has been recorded
+	cc.barrier <- struct{}{}
+
+	p.mu.Lock()
+	if !p.returned["1"] || p.returned["2"] || len(p.returned) != 2 {
+		t.Fatalf("expected only message 1 to be marked returned, got %v", p.returned)
+	}
+	p.mu.Unlock()
+
+	close(returns)
+	select {
+	case <-cc.closed:
+	case <-time.After(time.Second):
+		t.Fatal("expected the publisher to notice the closed channel")
+	}
+}
+
+func TestPublisher_WaitsForAttachedChannel(t *testing.T) {
+	p := NewPublisher(50 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+	if _, err := p.channel(ctx); !errors.Is(err, ErrNotConfirmed) {
+		t.Fatalf("expected ErrNotConfirmed without a channel, got %v", err)
+	}
+
+	// A closed channel is skipped until the next one is attached
+	old := newConfirmChannel(nil)
+	close(old.closed)
+	p.current = old
+	next := newConfirmChannel(nil)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		p.mu.Lock()
+		p.current = next
+		close(p.attached)
+		p.attached = make(chan struct{})
+		p.mu.Unlock()
+	}()
+	cc, err := p.channel(context.Background())
+	if err != nil || cc != next {
+		t.Fatalf("expected the newly attached channel, got %v, %v", cc, err)
+	}
+}
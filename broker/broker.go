@@ -0,0 +1,115 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ErrUnroutable is returned when the broker returned a mandatory message no queue is bound for
+var ErrUnroutable = errors.New("message could not be routed to any queue")
+
+// Exchange kinds
+const (
+	Direct = "direct"
+	Fanout = "fanout"
+	Topic  = "topic"
+)
+
+// Exchange is an exchange to declare
+type Exchange struct {
+	Name string
+	Kind string // Direct, Fanout or Topic
+}
+
+// Queue is a queue to declare. Auto-delete queues are deleted once their
+// last consumer goes away; exclusive ones belong to the declaring connection.
+type Queue struct {
+	Name       string
+	Durable    bool
+	AutoDelete bool
+	Exclusive  bool
+	Args       amqp.Table // x-max-priority, x-dead-letter-exchange
+}
+
+// Binding routes messages published to Exchange with a matching Key to Queue
+type Binding struct {
+	Queue    string
+	Key      string
+	Exchange string
+}
+
+// Topology is a set of exchanges, queues and bindings, declared in that order
+type Topology struct {
+	Exchanges []Exchange
+	Queues    []Queue
+	Bindings  []Binding
+}
+
+// Consumer consumes one or more queues into a single channel of deliveries
+type Consumer struct {
+	Queues   []string
+	Declare  Topology // Declared before consuming, e.g. the consumer's own queues
+	AutoAck  bool     // Deliveries count as acknowledged once delivered
+	Prefetch int      // Unacknowledged deliveries allowed across all Queues; 0 means no limit
+}
+
+// Broker publishes and consumes messages. Deliveries are settled with their
+// Ack, Nack and Reject methods; messages nacked or rejected without requeue
+// are dead-lettered when their queue has an x-dead-letter-exchange.
+//
+// It uses the amqp091 publishing, delivery and table types on purpose: both
+// services only run on RabbitMQ, and the memory broker exists to test them, so
+// the interface keeps AMQP's message model rather than wrapping it.
+type Broker interface {
+	// Declare declares the topology. It is declared again after reconnects.
+	Declare(t Topology) error
+	// Publish returns once the broker has the message. Mandatory messages no
+	// queue is bound for fail with ErrUnroutable.
+	Publish(exchange, key string, mandatory bool, msg amqp.Publishing) error
+	// Consume delivers the messages of the consumer's queues until ctx is done
+	// or the broker is closed, then closes the returned channel
+	Consume(ctx context.Context, c Consumer) <-chan amqp.Delivery
+	// Close closes the broker
+	Close() error
+}
+
+// Headers DeadLetter adds to say why a message failed and where it came from.
+// RabbitMQ records its own dead-lettering in the x-death header instead.
+const (
+	FailureReasonHeader    = "x-failure-reason"
+	FailedQueueHeader      = "x-failed-queue"
+	FailedExchangeHeader   = "x-failed-exchange"
+	FailedRoutingKeyHeader = "x-failed-routing-key"
+)
+
+// DeadLetter publishes a delivery from queue that can never be processed to
+// the dead-letter exchange with the reason and its origin in its headers, then
+// acknowledges it. If the dead letter cannot be published the delivery is
+// rejected instead, so the queue dead-letters it without the reason.
+func DeadLetter(b Broker, exchange, queue string, d amqp.Delivery, reason error) error {
+	headers := amqp.Table{}
+	maps.Copy(headers, d.Headers)
+	headers[FailureReasonHeader] = reason.Error()
+	headers[FailedQueueHeader] = queue
+	headers[FailedExchangeHeader] = d.Exchange
+	headers[FailedRoutingKeyHeader] = d.RoutingKey
+
+	err := b.Publish(exchange, d.RoutingKey, true, amqp.Publishing{
+		ContentType:  d.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Priority:     d.Priority,
+		MessageId:    d.MessageId,
+		Timestamp:    time.Now().UTC(),
+		Headers:      headers,
+		Body:         d.Body,
+	})
+	if err != nil {
+		d.Nack(false, false)
+		return err
+	}
+	return d.Ack(false)
+}
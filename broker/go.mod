@@ -0,0 +1,5 @@
+module mission_control/broker
+
+go 1.25.5
+
+require github.com/rabbitmq/amqp091-go v1.10.0
@@ -0,0 +1,555 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var (
+	// ErrNotFound is returned for exchanges and queues that were not declared
+	ErrNotFound = errors.New("not found")
+	// ErrUnknownDeliveryTag is returned when settling a delivery twice or one that was never delivered
+	ErrUnknownDeliveryTag = errors.New("unknown delivery tag")
+)
+
+// Memory is an in-memory Broker for tests. It routes like RabbitMQ - the
+// default exchange by queue name, direct, fanout and topic exchanges - and
+// keeps its delivery semantics: priorities up to x-max-priority, prefetch,
+// round-robin between consumers, redelivery of requeued messages and
+// dead-lettering with an x-death header. Messages are lost when it is closed.
+type Memory struct {
+	mu        sync.Mutex
+	exchanges map[string]string // name -> kind
+	queues    map[string]*memoryQueue
+	bindings  []Binding
+	consumers int // source of consumer tags
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// memoryQueue holds the messages ready for delivery, highest priority first
+type memoryQueue struct {
+	Queue
+	messages  []amqp.Delivery
+	consumers []*memoryConsumer
+	next      int // round-robin position among consumers
+}
+
+// memoryConsumer is a Consumer registered on its queues. It settles its
+// deliveries, so it is their acknowledger.
+type memoryConsumer struct {
+	broker   *Memory
+	tag      string
+	autoAck  bool
+	prefetch int
+	queues   []*memoryQueue
+	lastTag  uint64
+	unacked  map[uint64]unacked
+	pending  []amqp.Delivery // delivered but not yet received from the channel
+	wake     chan struct{}
+}
+
+// unacked is a delivered message awaiting its acknowledgement
+type unacked struct {
+	msg   amqp.Delivery
+	queue *memoryQueue
+}
+
+var _ Broker = (*Memory)(nil)
+
+// NewMemory returns an empty in-memory broker
+func NewMemory() *Memory {
+	return &Memory{
+		exchanges: map[string]string{"": Direct},
+		queues:    make(map[string]*memoryQueue),
+		done:      make(chan struct{}),
+	}
+}
+
+// Declare declares the topology. Redeclaring existing exchanges and queues is
+// a no-op; bindings to missing exchanges or queues fail with ErrNotFound.
+func (m *Memory) Declare(t Topology) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.declare(t)
+}
+
+// declare declares the topology; m.mu must be held
+func (m *Memory) declare(t Topology) error {
+	for _, e := range t.Exchanges {
+		if _, ok := m.exchanges[e.Name]; !ok {
+			m.exchanges[e.Name] = e.Kind
+		}
+	}
+	for _, q := range t.Queues {
+		if _, ok := m.queues[q.Name]; !ok {
+			m.queues[q.Name] = &memoryQueue{Queue: q}
+		}
+	}
+	for _, b := range t.Bindings {
+		if _, ok := m.exchanges[b.Exchange]; !ok {
+			return fmt.Errorf("exchange %q: %w", b.Exchange, ErrNotFound)
+		}
+		if _, ok := m.queues[b.Queue]; !ok {
+			return fmt.Errorf("queue %q: %w", b.Queue, ErrNotFound)
+		}
+		if !slices.Contains(m.bindings, b) {
+			m.bindings = append(m.bindings, b)
+		}
+	}
+	return nil
+}
+
+// Publish routes msg to every matching queue. Unroutable messages are
+// dropped, or fail with ErrUnroutable if they are mandatory.
+func (m *Memory) Publish(exchange, key string, mandatory bool, msg amqp.Publishing) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	queues, err := m.route(exchange, key)
+	if err != nil {
+		return err
+	}
+	if len(queues) == 0 {
+		if mandatory {
+			return fmt.Errorf("%w: exchange %q, routing key %s", ErrUnroutable, exchange, key)
+		}
+		return nil
+	}
+	for _, q := range queues {
+		m.enqueue(q, amqp.Delivery{
+			Headers:         cloneTable(msg.Headers),
+			ContentType:     msg.ContentType,
+			ContentEncoding: msg.ContentEncoding,
+			DeliveryMode:    msg.DeliveryMode,
+			Priority:        msg.Priority,
+			CorrelationId:   msg.CorrelationId,
+			ReplyTo:         msg.ReplyTo,
+			Expiration:      msg.Expiration,
+			MessageId:       msg.MessageId,
+			Timestamp:       msg.Timestamp,
+			Type:            msg.Type,
+			UserId:          msg.UserId,
+			AppId:           msg.AppId,
+			Exchange:        exchange,
+			RoutingKey:      key,
+			Body:            slices.Clone(msg.Body),
+		}, false)
+	}
+	return nil
+}
+
+// route returns the queues a message published to exchange with key goes to
+func (m *Memory) route(exchange, key string) ([]*memoryQueue, error) {
+	kind, ok := m.exchanges[exchange]
+	if !ok {
+		return nil, fmt.Errorf("exchange %q: %w", exchange, ErrNotFound)
+	}
+	if exchange == "" {
+		if q, ok := m.queues[key]; ok {
+			return []*memoryQueue{q}, nil
+		}
+		return nil, nil
+	}
+	var queues []*memoryQueue
+	for _, b := range m.bindings {
+		if b.Exchange != exchange || !matchesKey(kind, b.Key, key) {
+			continue
+		}
+		if q := m.queues[b.Queue]; !slices.Contains(queues, q) {
+			queues = append(queues, q)
+		}
+	}
+	return queues, nil
+}
+
+// matchesKey reports whether a binding key of an exchange of the given kind
+// matches a routing key. Topic binding keys match dot-separated words, where
+// "*" stands for one word and "#" for zero or more.
+func matchesKey(kind, binding, key string) bool {
+	switch kind {
+	case Fanout:
+		return true
+	case Topic:
+		return matchesWords(strings.Split(binding, "."), strings.Split(key, "."))
+	}
+	return binding == key
+}
+
+// matchesWords matches topic binding words against routing key words
+func matchesWords(pattern, words []string) bool {
+	if len(pattern) == 0 {
+		return len(words) == 0
+	}
+	switch pattern[0] {
+	case "#":
+		for i := 0; i <= len(words); i++ {
+			if matchesWords(pattern[1:], words[i:]) {
+				return true
+			}
+		}
+		return false
+	case "*":
+		return len(words) > 0 && matchesWords(pattern[1:], words[1:])
+	}
+	return len(words) > 0 && pattern[0] == words[0] && matchesWords(pattern[1:], words[1:])
+}
+
+// enqueue adds a message behind those of the same priority, or in front of
+// them when it is requeued, and delivers what the consumers can take
+func (m *Memory) enqueue(q *memoryQueue, msg amqp.Delivery, requeued bool) {
+	priority := q.priority(msg)
+	i := 0
+	for i < len(q.messages) {
+		p := q.priority(q.messages[i])
+		if p < priority || (requeued && p == priority) {
+			break
+		}
+		i++
+	}
+	q.messages = slices.Insert(q.messages, i, msg)
+	m.dispatch(q)
+}
+
+// priority returns the effective priority of a message in the queue: none
+// without x-max-priority, otherwise capped at it
+func (q *memoryQueue) priority(msg amqp.Delivery) uint8 {
+	limit, ok := toInt(q.Args["x-max-priority"])
+	if !ok {
+		return 0
+	}
+	return uint8(min(int(msg.Priority), limit))
+}
+
+// dispatch hands ready messages to consumers with room for them, round-robin
+func (m *Memory) dispatch(q *memoryQueue) {
+	for len(q.messages) > 0 {
+		c := q.nextConsumer()
+		if c == nil {
+			return
+		}
+		msg := q.messages[0]
+		q.messages = q.messages[1:]
+		c.deliver(q, msg)
+	}
+}
+
+// nextConsumer returns the next consumer in turn that may take a message
+func (q *memoryQueue) nextConsumer() *memoryConsumer {
+	for range q.consumers {
+		c := q.consumers[q.next%len(q.consumers)]
+		q.next = (q.next + 1) % len(q.consumers)
+		if c.autoAck || c.prefetch == 0 || len(c.unacked) < c.prefetch {
+			return c
+		}
+	}
+	return nil
+}
+
+// Consume declares the consumer's topology and registers it on its queues.
+// The deliveries of an auto-ack consumer are settled when delivered. When ctx
+// is done, deliveries not yet received from the channel are requeued;
+// received ones can still be settled.
+func (m *Memory) Consume(ctx context.Context, consumer Consumer) <-chan amqp.Delivery {
+	out := make(chan amqp.Delivery)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.consumers++
+	c := &memoryConsumer{
+		broker:   m,
+		tag:      fmt.Sprintf("memory-consumer-%d", m.consumers),
+		autoAck:  consumer.AutoAck,
+		prefetch: consumer.Prefetch,
+		unacked:  make(map[uint64]unacked),
+		wake:     make(chan struct{}, 1),
+	}
+	if err := m.declare(consumer.Declare); err != nil {
+		close(out)
+		return out
+	}
+	for _, name := range consumer.Queues {
+		q, ok := m.queues[name]
+		if !ok {
+			close(out)
+			return out
+		}
+		c.queues = append(c.queues, q)
+	}
+	for _, q := range c.queues {
+		q.consumers = append(q.consumers, c)
+	}
+	for _, q := range c.queues {
+		m.dispatch(q)
+	}
+	go c.forward(ctx, out)
+	return out
+}
+
+// deliver tags a message for the consumer; m.mu must be held
+func (c *memoryConsumer) deliver(q *memoryQueue, msg amqp.Delivery) {
+	c.lastTag++
+	msg.DeliveryTag = c.lastTag
+	msg.ConsumerTag = c.tag
+	if !c.autoAck {
+		msg.Acknowledger = c
+		c.unacked[msg.DeliveryTag] = unacked{msg: msg, queue: q}
+	}
+	c.pending = append(c.pending, msg)
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// forward passes pending deliveries to out until ctx is done or the broker closes
+func (c *memoryConsumer) forward(ctx context.Context, out chan<- amqp.Delivery) {
+	defer close(out)
+	defer c.cancel()
+	m := c.broker
+	for {
+		m.mu.Lock()
+		if len(c.pending) == 0 {
+			m.mu.Unlock()
+			select {
+			case <-c.wake:
+				continue
+			case <-ctx.Done():
+				return
+			case <-m.done:
+				return
+			}
+		}
+		d := c.pending[0]
+		c.pending = c.pending[1:]
+		m.mu.Unlock()
+
+		select {
+		case out <- d:
+		case <-ctx.Done():
+			m.mu.Lock()
+			c.pending = slices.Insert(c.pending, 0, d)
+			m.mu.Unlock()
+			return
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// cancel unregisters the consumer, requeues the deliveries it did not pass
+// on and deletes auto-delete queues it was the last consumer of
+func (c *memoryConsumer) cancel() {
+	m := c.broker
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, q := range c.queues {
+		q.consumers = slices.DeleteFunc(q.consumers, func(other *memoryConsumer) bool { return other == c })
+	}
+	pending := c.pending
+	c.pending = nil
+	for _, d := range slices.Backward(pending) {
+		if entry, ok := c.unacked[d.DeliveryTag]; ok {
+			delete(c.unacked, d.DeliveryTag)
+			m.requeue(entry)
+		}
+	}
+	for _, q := range c.queues {
+		if q.AutoDelete && len(q.consumers) == 0 {
+			m.deleteQueue(q)
+		}
+	}
+}
+
+// deleteQueue drops the queue with its messages and bindings
+func (m *Memory) deleteQueue(q *memoryQueue) {
+	delete(m.queues, q.Name)
+	m.bindings = slices.DeleteFunc(m.bindings, func(b Binding) bool { return b.Queue == q.Name })
+}
+
+// requeue puts an unacknowledged message back at the front of its queue
+func (m *Memory) requeue(entry unacked) {
+	if m.queues[entry.queue.Name] != entry.queue {
+		return // the queue was deleted
+	}
+	msg := entry.msg
+	msg.Redelivered = true
+	msg.DeliveryTag, msg.ConsumerTag, msg.Acknowledger = 0, "", nil
+	m.enqueue(entry.queue, msg, true)
+}
+
+// Ack acknowledges the delivery, or every delivery up to it with multiple
+func (c *memoryConsumer) Ack(tag uint64, multiple bool) error {
+	return c.settle(tag, multiple, func(entry unacked) {})
+}
+
+// Nack rejects the delivery, or every delivery up to it with multiple. Rejected
+// messages are requeued, or dead-lettered if their queue has a dead-letter exchange.
+func (c *memoryConsumer) Nack(tag uint64, multiple, requeue bool) error {
+	m := c.broker
+	return c.settle(tag, multiple, func(entry unacked) {
+		if requeue {
+			m.requeue(entry)
+		} else {
+			m.deadLetter(entry)
+		}
+	})
+}
+
+// Reject rejects a single delivery like Nack
+func (c *memoryConsumer) Reject(tag uint64, requeue bool) error {
+	return c.Nack(tag, false, requeue)
+}
+
+// settle removes the acknowledged deliveries, applies f to each of them and
+// hands the freed prefetch capacity to the next messages
+func (c *memoryConsumer) settle(tag uint64, multiple bool, f func(entry unacked)) error {
+	m := c.broker
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var tags []uint64
+	if multiple {
+		for t := range c.unacked {
+			if t <= tag {
+				tags = append(tags, t)
+			}
+		}
+		slices.Sort(tags)
+	} else if _, ok := c.unacked[tag]; ok {
+		tags = []uint64{tag}
+	}
+	if len(tags) == 0 {
+		return fmt.Errorf("%w: %d", ErrUnknownDeliveryTag, tag)
+	}
+	for _, t := range tags {
+		entry := c.unacked[t]
+		delete(c.unacked, t)
+		f(entry)
+	}
+	for _, q := range c.queues {
+		if m.queues[q.Name] == q {
+			m.dispatch(q)
+		}
+	}
+	return nil
+}
+
+// deadLetter republishes a rejected message to its queue's dead-letter
+// exchange with the x-death header RabbitMQ adds, or drops it
+func (m *Memory) deadLetter(entry unacked) {
+	exchange, ok := entry.queue.Args["x-dead-letter-exchange"].(string)
+	if !ok {
+		return
+	}
+	msg := entry.msg
+	headers := cloneTable(msg.Headers)
+	if headers == nil {
+		headers = amqp.Table{}
+	}
+	deaths, _ := headers["x-death"].([]any)
+	count := int64(1)
+	deaths = slices.DeleteFunc(slices.Clone(deaths), func(d any) bool {
+		death, _ := d.(amqp.Table)
+		if death["queue"] == entry.queue.Name && death["reason"] == "rejected" {
+			previous, _ := death["count"].(int64)
+			count += previous
+			return true
+		}
+		return false
+	})
+	death := amqp.Table{
+		"queue":        entry.queue.Name,
+		"reason":       "rejected",
+		"count":        count,
+		"exchange":     msg.Exchange,
+		"routing-keys": []any{msg.RoutingKey},
+		"time":         time.Now().UTC().Truncate(time.Second),
+	}
+	headers["x-death"] = append([]any{death}, deaths...)
+
+	queues, _ := m.route(exchange, msg.RoutingKey)
+	for _, q := range queues {
+		dead := msg
+		dead.Headers = cloneTable(headers)
+		dead.Exchange = exchange
+		dead.Redelivered = false
+		dead.DeliveryTag, dead.ConsumerTag, dead.Acknowledger = 0, "", nil
+		m.enqueue(q, dead, false)
+	}
+}
+
+// Len returns how many messages of the queue are waiting for delivery
+func (m *Memory) Len(queue string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if q, ok := m.queues[queue]; ok {
+		return len(q.messages)
+	}
+	return 0
+}
+
+// Get removes and returns the next message waiting in the queue, for tests
+// that inspect what was published without consuming
+func (m *Memory) Get(queue string) (amqp.Delivery, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	q, ok := m.queues[queue]
+	if !ok || len(q.messages) == 0 {
+		return amqp.Delivery{}, false
+	}
+	msg := q.messages[0]
+	q.messages = q.messages[1:]
+	return msg, true
+}
+
+// Close stops every consumer
+func (m *Memory) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	return nil
+}
+
+// cloneTable copies a header table one level deep
+func cloneTable(t amqp.Table) amqp.Table {
+	if t == nil {
+		return nil
+	}
+	clone := make(amqp.Table, len(t))
+	for k, v := range t {
+		clone[k] = v
+	}
+	return clone
+}
+
+// toInt converts the integer types an amqp.Table may hold
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,198 @@
+package broker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// receive returns the next delivery or fails the test
+func receive(t *testing.T, msgs <-chan amqp.Delivery) amqp.Delivery {
+	t.Helper()
+	select {
+	case d, ok := <-msgs:
+		if !ok {
+			t.Fatal("deliveries closed")
+		}
+		return d
+	case <-time.After(time.Second):
+		t.Fatal("expected a delivery")
+	}
+	return amqp.Delivery{}
+}
+
+// expectNone fails the test if a delivery arrives shortly
+func expectNone(t *testing.T, msgs <-chan amqp.Delivery) {
+	t.Helper()
+	select {
+	case d := <-msgs:
+		t.Fatalf("expected no delivery, got %s", d.Body)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestMatchesKey(t *testing.T) {
+	cases := []struct {
+		kind, binding, key string
+		expected           bool
+	}{
+		{Direct, "orders", "orders", true},
+		{Direct, "orders", "orders.x", false},
+		{Fanout, "", "anything", true},
+		{Topic, "soldier.s1", "soldier.s1", true},
+		{Topic, "soldier.*", "soldier.s1", true},
+		{Topic, "soldier.*", "soldier.s1.x", false},
+		{Topic, "soldier.#", "soldier", true},
+		{Topic, "#.s1", "unit.alpha.s1", true},
+		{Topic, "unit.*", "soldier.s1", false},
+	}
+	for _, c := range cases {
+		if got := matchesKey(c.kind, c.binding, c.key); got != c.expected {
+			t.Fatalf("%s binding %q, key %q: expected %v, got %v", c.kind, c.binding, c.key, c.expected, got)
+		}
+	}
+}
+
+func TestMemory_Routing(t *testing.T) {
+	m := NewMemory()
+	err := m.Declare(Topology{
+		Exchanges: []Exchange{{Name: "control", Kind: Fanout}, {Name: "orders", Kind: Topic}},
+		Queues:    []Queue{{Name: "a"}, {Name: "b"}},
+		Bindings:  []Binding{{Queue: "a", Exchange: "control"}, {Queue: "b", Exchange: "control"}, {Queue: "b", Key: "unit.*", Exchange: "orders"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m.Publish("", "a", true, amqp.Publishing{Body: []byte("direct")})
+	m.Publish("control", "", true, amqp.Publishing{Body: []byte("broadcast")})
+	m.Publish("orders", "unit.alpha", true, amqp.Publishing{Body: []byte("targeted")})
+	if m.Len("a") != 2 || m.Len("b") != 2 {
+		t.Fatalf("expected 2 messages per queue, got %d and %d", m.Len("a"), m.Len("b"))
+	}
+	if d, _ := m.Get("b"); string(d.Body) != "broadcast" || d.Exchange != "control" {
+		t.Fatalf("unexpected message %+v", d)
+	}
+
+	if err := m.Publish("orders", "soldier.s9", true, amqp.Publishing{}); !errors.Is(err, ErrUnroutable) {
+		t.Fatalf("expected ErrUnroutable for a mandatory message, got %v", err)
+	}
+	if err := m.Publish("orders", "soldier.s9", false, amqp.Publishing{}); err != nil {
+		t.Fatalf("expected unroutable messages to be dropped, got %v", err)
+	}
+	if err := m.Publish("missing", "", false, amqp.Publishing{}); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound for an undeclared exchange, got %v", err)
+	}
+}
+
+func TestMemory_Priority(t *testing.T) {
+	m := NewMemory()
+	m.Declare(Topology{Queues: []Queue{{Name: "q", Args: amqp.Table{"x-max-priority": 5}}}})
+	for _, p := range []uint8{1, 9, 3, 5} {
+		m.Publish("", "q", false, amqp.Publishing{Priority: p, Body: []byte{p}})
+	}
+
+	// 9 is capped to the maximum of 5 and stays ahead of the later 5
+	var order []byte
+	for m.Len("q") > 0 {
+		d, _ := m.Get("q")
+		order = append(order, d.Body[0])
+	}
+	if string(order) != string([]byte{9, 5, 3, 1}) {
+		t.Fatalf("expected priority order 9 5 3 1, got %v", order)
+	}
+}
+
+func TestMemory_PrefetchAndRoundRobin(t *testing.T) {
+	m := NewMemory()
+	m.Declare(Topology{Queues: []Queue{{Name: "q"}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := m.Consume(ctx, Consumer{Queues: []string{"q"}, Prefetch: 1})
+	second := m.Consume(ctx, Consumer{Queues: []string{"q"}, Prefetch: 1})
+	for _, body := range []string{"1", "2", "3"} {
+		m.Publish("", "q", false, amqp.Publishing{Body: []byte(body)})
+	}
+
+	d1, d2 := receive(t, first), receive(t, second)
+	if string(d1.Body) != "1" || string(d2.Body) != "2" {
+		t.Fatalf("expected round-robin delivery, got %s and %s", d1.Body, d2.Body)
+	}
+	// Both consumers are at their prefetch limit until they acknowledge
+	expectNone(t, first)
+	if m.Len("q") != 1 {
+		t.Fatalf("expected the third message to wait in the queue")
+	}
+	if err := d2.Ack(false); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d := receive(t, second); string(d.Body) != "3" {
+		t.Fatalf("expected the third message after the ack, got %s", d.Body)
+	}
+	if err := d2.Ack(false); !errors.Is(err, ErrUnknownDeliveryTag) {
+		t.Fatalf("expected ErrUnknownDeliveryTag for a second ack, got %v", err)
+	}
+}
+
+func TestMemory_RequeueAndDeadLetter(t *testing.T) {
+	m := NewMemory()
+	m.Declare(Topology{
+		Exchanges: []Exchange{{Name: "dlx", Kind: Fanout}},
+		Queues:    []Queue{{Name: "q", Args: amqp.Table{"x-dead-letter-exchange": "dlx"}}, {Name: "dead"}},
+		Bindings:  []Binding{{Queue: "dead", Exchange: "dlx"}},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs := m.Consume(ctx, Consumer{Queues: []string{"q"}})
+	m.Publish("", "q", false, amqp.Publishing{Body: []byte("poison")})
+
+	d := receive(t, msgs)
+	if d.Redelivered {
+		t.Fatal("expected a first delivery")
+	}
+	d.Nack(false, true)
+	d = receive(t, msgs)
+	if !d.Redelivered {
+		t.Fatal("expected the requeued message to be redelivered")
+	}
+	d.Nack(false, false)
+
+	dead, ok := m.Get("dead")
+	if !ok || string(dead.Body) != "poison" || dead.RoutingKey != "q" {
+		t.Fatalf("expected the message dead-lettered with its routing key, got %+v", dead)
+	}
+	deaths, _ := dead.Headers["x-death"].([]any)
+	death, _ := deaths[0].(amqp.Table)
+	if death["queue"] != "q" || death["reason"] != "rejected" || death["count"] != int64(1) {
+		t.Fatalf("unexpected x-death %v", deaths)
+	}
+}
+
+func TestMemory_CancelRequeuesUnreceived(t *testing.T) {
+	m := NewMemory()
+	m.Declare(Topology{Queues: []Queue{{Name: "q"}, {Name: "private", AutoDelete: true}}})
+	ctx, cancel := context.WithCancel(context.Background())
+	msgs := m.Consume(ctx, Consumer{Queues: []string{"q", "private"}})
+	m.Publish("", "q", false, amqp.Publishing{Body: []byte("1")})
+	m.Publish("", "q", false, amqp.Publishing{Body: []byte("2")})
+
+	received := receive(t, msgs)
+	cancel()
+	for range msgs {
+	}
+
+	// The unreceived message is back in the queue; the received one can still be acknowledged
+	if m.Len("q") != 1 {
+		t.Fatalf("expected the unreceived message to be requeued, got %d", m.Len("q"))
+	}
+	if err := received.Ack(false); err != nil {
+		t.Fatalf("expected a received delivery to stay acknowledgeable, got %v", err)
+	}
+	if err := m.Publish("", "private", true, amqp.Publishing{}); !errors.Is(err, ErrUnroutable) {
+		t.Fatalf("expected the auto-delete queue to be gone, got %v", err)
+	}
+}
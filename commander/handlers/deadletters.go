@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/rabbitmq"
+	"mission_control/commander/store"
+	"mission_control/commander/utils"
+)
+
+// loadDeadLetter answers 404 for unknown or malformed IDs and returns the
+// dead letter named by the id path value otherwise
+func loadDeadLetter(w http.ResponseWriter, r *http.Request, letters store.DeadLetterStore) (*models.DeadLetter, bool) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		utils.RenderJsonMessage(map[string]string{"message": "Dead letter not found"}, w, http.StatusNotFound)
+		return nil, false
+	}
+	letter, err := letters.DeadLetter(id)
+	if err == store.ErrDeadLetterNotFound {
+		utils.RenderJsonMessage(map[string]string{"message": "Dead letter not found"}, w, http.StatusNotFound)
+		return nil, false
+	}
+	if err != nil {
+		utils.RenderJsonMessage(map[string]string{"message": "Failed to load dead letter"}, w, http.StatusInternalServerError)
+		return nil, false
+	}
+	return letter, true
+}
+
+// ListDeadLettersHandler lists dead-lettered messages, oldest first,
+// optionally only those of the queue given by ?queue=
+func ListDeadLettersHandler(letters store.DeadLetterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		list, err := letters.DeadLetters(r.URL.Query().Get("queue"))
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to list dead letters"}, w, http.StatusInternalServerError)
+			return
+		}
+		if list == nil {
+			list = []models.DeadLetter{}
+		}
+		utils.RenderJsonMessage(map[string]any{"dead_letters": list}, w, http.StatusOK)
+	}
+}
+
+// GetDeadLetterHandler returns one dead-lettered message with its headers and body
+func GetDeadLetterHandler(letters store.DeadLetterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if letter, ok := loadDeadLetter(w, r, letters); ok {
+			utils.RenderJsonMessage(letter, w, http.StatusOK)
+		}
+	}
+}
+
+// ReplayDeadLetterHandler republishes a dead-lettered message to its original
+// exchange and routing key and forgets it once the publish succeeded
+func ReplayDeadLetterHandler(publisher broker.Broker, letters store.DeadLetterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		letter, ok := loadDeadLetter(w, r, letters)
+		if !ok {
+			return
+		}
+		if err := rabbitmq.ReplayDeadLetter(publisher, letter); err != nil {
+			log.Printf("Failed to replay dead letter %d: %v", letter.ID, err)
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to replay dead letter"}, w, http.StatusInternalServerError)
+			return
+		}
+		if err := letters.DeleteDeadLetter(letter.ID); err != nil {
+			log.Printf("Replayed dead letter %d could not be deleted: %v", letter.ID, err)
+		}
+		log.Printf("Dead letter %d replayed to %q with routing key %s", letter.ID, letter.Exchange, letter.RoutingKey)
+		data := map[string]any{
+			"id":          letter.ID,
+			"exchange":    letter.Exchange,
+			"routing_key": letter.RoutingKey,
+			"message":     "Dead letter replayed",
+		}
+		utils.RenderJsonMessage(data, w, http.StatusOK)
+	}
+}
+
+// DeleteDeadLetterHandler discards one dead-lettered message
+func DeleteDeadLetterHandler(letters store.DeadLetterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		letter, ok := loadDeadLetter(w, r, letters)
+		if !ok {
+			return
+		}
+		if err := letters.DeleteDeadLetter(letter.ID); err != nil && err != store.ErrDeadLetterNotFound {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to delete dead letter"}, w, http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PurgeDeadLettersHandler discards every dead-lettered message, or only those
+// of the queue given by ?queue=
+func PurgeDeadLettersHandler(letters store.DeadLetterStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		queue := r.URL.Query().Get("queue")
+		purged, err := letters.PurgeDeadLetters(queue)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to purge dead letters"}, w, http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Purged %d dead letters (queue %q)", purged, queue)
+		utils.RenderJsonMessage(map[string]int{"purged": purged}, w, http.StatusOK)
+	}
+}
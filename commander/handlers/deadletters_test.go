@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+func TestDeadLetterHandlers(t *testing.T) {
+	letters := store.NewMemoryStore()
+	letters.AddDeadLetter(&models.DeadLetter{Queue: "status_queue", Reason: "rejected", Body: "{not json"})
+	letters.AddDeadLetter(&models.DeadLetter{Queue: "orders_queue", Reason: "rejected", Body: "{}"})
+
+	rr := httptest.NewRecorder()
+	ListDeadLettersHandler(letters)(rr, httptest.NewRequest("GET", "/admin/dead-letters?queue=status_queue", nil))
+	var resp struct {
+		DeadLetters []models.DeadLetter `json:"dead_letters"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusOK || len(resp.DeadLetters) != 1 || resp.DeadLetters[0].Body != "{not json" {
+		t.Fatalf("expected the status_queue dead letter, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	for id, expected := range map[string]int{"1": http.StatusOK, "99": http.StatusNotFound, "abc": http.StatusNotFound} {
+		req := httptest.NewRequest("GET", "/admin/dead-letters/"+id, nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+		GetDeadLetterHandler(letters)(rr, req)
+		if rr.Code != expected {
+			t.Fatalf("get %s: expected %d, got %d", id, expected, rr.Code)
+		}
+	}
+
+	// Unknown dead letters are not replayed
+	req := httptest.NewRequest("POST", "/admin/dead-letters/99/replay", nil)
+	req.SetPathValue("id", "99")
+	rr = httptest.NewRecorder()
+	ReplayDeadLetterHandler(nil, letters)(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("replay: expected 404, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest("DELETE", "/admin/dead-letters/1", nil)
+	req.SetPathValue("id", "1")
+	rr = httptest.NewRecorder()
+	DeleteDeadLetterHandler(letters)(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	PurgeDeadLettersHandler(letters)(rr, httptest.NewRequest("DELETE", "/admin/dead-letters", nil))
+	if rr.Code != http.StatusOK || rr.Body.String() == "" {
+		t.Fatalf("purge: expected 200, got %d", rr.Code)
+	}
+	if remaining, _ := letters.DeadLetters(""); len(remaining) != 0 {
+		t.Fatalf("expected every dead letter to be purged, got %+v", remaining)
+	}
+}
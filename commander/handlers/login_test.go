@@ -69,6 +69,8 @@ func TestGenerateJWT(t *testing.T) {
 
 }
 func TestLoginHandler(t *testing.T) {
+	t.Setenv("COMMANDER_API_KEY", "dummy_commander_secret_key")
+
 	// --- Prepare JSON Payload ---
 	payload := map[string]string{
 		"user":    "COMMANDER",
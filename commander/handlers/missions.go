@@ -1,24 +1,198 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/config"
 	"mission_control/commander/models"
+	"mission_control/commander/orders"
 	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
 	"mission_control/commander/store"
 	"mission_control/commander/utils"
+	"mission_control/commander/webhooks"
 
 	"github.com/google/uuid"
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// CreateMissionHandler creates a new mission and publishes it to RabbitMQ
-func CreateMissionHandler(ch *amqp.Channel) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		var req struct {
-			Order string `json:"order"`
+// createMissionRequest is the body of POST /missions
+type createMissionRequest struct {
+	Order     string          `json:"order"`
+	Type      string          `json:"type,omitempty"`   // Registered order type, validates params
+	Params    json.RawMessage `json:"params,omitempty"` // Parameters of the typed order
+	Priority  *int            `json:"priority,omitempty"`
+	ExecuteAt *time.Time      `json:"execute_at,omitempty"` // Publish the mission at this time
+	Delay     string          `json:"delay,omitempty"`      // Publish the mission after this duration, e.g. "90s"
+	Timeout   string          `json:"timeout,omitempty"`    // Maximum IN_PROGRESS time, e.g. "5m"
+	Target    string          `json:"target,omitempty"`     // "soldier:<id>", "unit:<name>" or "any"
+	// Capabilities a soldier needs, in addition to those of the order type
+	Capabilities []string `json:"capabilities,omitempty"`
+	// CallbackURL receives a webhook on every status transition
+	CallbackURL string `json:"callback_url,omitempty"`
+	// MaxAttempts is how many attempts the mission gets when it fails on the soldier
+	MaxAttempts *int            `json:"max_attempts,omitempty"`
+	Backoff     *backoffRequest `json:"backoff,omitempty"`
+}
+
+// backoffRequest spaces the attempts of a mission; durations look like "5s"
+type backoffRequest struct {
+	Type    string `json:"type,omitempty"`    // "fixed" or "exponential" (default)
+	Initial string `json:"initial,omitempty"` // Wait after the first failure, 5s by default
+	Max     string `json:"max,omitempty"`     // Longest exponential wait, 5m by default
+}
+
+// hash identifies the decoded request, ignoring formatting differences in the raw body
+func (req createMissionRequest) hash() string {
+	body, _ := json.Marshal(req)
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// executeAt returns when a scheduled mission is due, or nil for immediate missions
+func (req createMissionRequest) executeAt(now time.Time) (*time.Time, error) {
+	if req.ExecuteAt != nil && req.Delay != "" {
+		return nil, errors.New("use either execute_at or delay, not both")
+	}
+	at := req.ExecuteAt
+	if req.Delay != "" {
+		delay, err := time.ParseDuration(req.Delay)
+		if err != nil || delay < 0 {
+			return nil, errors.New("delay must be a positive duration such as 90s or 5m")
+		}
+		t := now.Add(delay)
+		at = &t
+	}
+	// Times that are not in the future are published right away
+	if at == nil || !at.After(now) {
+		return nil, nil
+	}
+	t := at.UTC()
+	return &t, nil
+}
+
+// timeoutSeconds returns the requested timeout, or the given default
+func (req createMissionRequest) timeoutSeconds(timeout time.Duration) (int64, error) {
+	if req.Timeout != "" {
+		t, err := time.ParseDuration(req.Timeout)
+		if err != nil || t < time.Second {
+			return 0, errors.New("timeout must be a duration of at least 1s, such as 5m")
+		}
+		timeout = t
+	}
+	return int64(timeout / time.Second), nil
+}
+
+// retryPolicy returns the requested number of attempts and, for missions
+// that are retried, their backoff
+func (req createMissionRequest) retryPolicy() (int, *models.Backoff, error) {
+	attempts := 1
+	if req.MaxAttempts != nil {
+		attempts = *req.MaxAttempts
+	}
+	if attempts < 1 || attempts > models.MaxAttemptsLimit {
+		return 0, nil, fmt.Errorf("max_attempts must be between 1 and %d", models.MaxAttemptsLimit)
+	}
+	backoff := models.DefaultBackoff
+	if req.Backoff != nil {
+		switch req.Backoff.Type {
+		case "":
+		case models.BackoffFixed, models.BackoffExponential:
+			backoff.Type = req.Backoff.Type
+		default:
+			return 0, nil, errors.New(`backoff type must be "fixed" or "exponential"`)
+		}
+		if req.Backoff.Initial != "" {
+			initial, err := time.ParseDuration(req.Backoff.Initial)
+			if err != nil || initial < time.Second {
+				return 0, nil, errors.New("backoff initial must be a duration of at least 1s, such as 10s")
+			}
+			backoff.InitialSeconds = int64(initial / time.Second)
+		}
+		if req.Backoff.Max != "" {
+			limit, err := time.ParseDuration(req.Backoff.Max)
+			if err != nil || limit < time.Second {
+				return 0, nil, errors.New("backoff max must be a duration of at least 1s, such as 5m")
+			}
+			backoff.MaxSeconds = int64(limit / time.Second)
+		}
+		if backoff.MaxSeconds < backoff.InitialSeconds {
+			return 0, nil, errors.New("backoff max must not be shorter than initial")
 		}
+	}
+	if attempts == 1 {
+		return attempts, nil, nil
+	}
+	return attempts, &backoff, nil
+}
+
+// invalidParams answers a typed order whose params do not match the order type's schema
+func invalidParams(w http.ResponseWriter, orderType string, fieldErrors []orders.FieldError) {
+	data := map[string]any{
+		"message": fmt.Sprintf("Invalid params for order type %s", orderType),
+		"errors":  fieldErrors,
+	}
+	utils.RenderJsonMessage(data, w, http.StatusBadRequest)
+}
+
+// validCallbackURL reports whether the URL is an absolute http(s) URL
+func validCallbackURL(callbackURL string) bool {
+	u, err := url.Parse(callbackURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return false
+	}
+	// Names are checked when the webhook is delivered, once they are resolved
+	if ip, err := netip.ParseAddr(u.Hostname()); err == nil {
+		return webhooks.IsPublic(ip)
+	}
+	return u.Hostname() != "localhost"
+}
+
+// requiredCapabilities merges the requested capabilities with those of the
+// order type into a sorted list without duplicates
+func requiredCapabilities(requested []string, orderType *orders.OrderType) ([]string, error) {
+	var capabilities []string
+	if orderType != nil {
+		capabilities = append(capabilities, orderType.Capabilities...)
+	}
+	for _, capability := range requested {
+		capability = strings.ToLower(strings.TrimSpace(capability))
+		if capability == "" {
+			return nil, errors.New("capabilities must not be empty")
+		}
+		capabilities = append(capabilities, capability)
+	}
+	slices.Sort(capabilities)
+	return slices.Compact(capabilities), nil
+}
+
+// IdempotencyKeyHeader lets clients retry POST /missions without creating duplicates
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// CreateMissionHandler creates a new mission and publishes it to RabbitMQ.
+// Missions with execute_at or delay are stored as SCHEDULED and published
+// later by the scheduler, which also republishes missions with max_attempts
+// that failed on the soldier. Requests carrying an Idempotency-Key are only
+// executed once per key; repeats within the configured window replay the
+// first response. Typed orders carry params that are validated against the
+// order type's schema before anything is stored or published. Missions that
+// require capabilities no registered soldier has are rejected with 409.
+func CreateMissionHandler(publisher broker.Broker, missions store.MissionStore, keys store.IdempotencyStore, orderTypes *orders.Registry, soldiers *registry.Registry) http.HandlerFunc {
+	ttl := config.GetIdempotencyTTL()
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req createMissionRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			data := map[string]string{
 				"message": "Invalid request",
@@ -26,47 +200,438 @@ func CreateMissionHandler(ch *amqp.Channel) http.HandlerFunc {
 			utils.RenderJsonMessage(data, w, http.StatusBadRequest)
 			return
 		}
-		if req.Order == "" {
+		if req.Order == "" && req.Type == "" {
 			data := map[string]string{
-				"message": "Require order",
+				"message": "Require order or type",
 			}
 			utils.RenderJsonMessage(data, w, http.StatusBadRequest)
 			return
 		}
-		mission := &models.Mission{
-			MissionID: uuid.New().String(),
-			Order:     req.Order,
-			Status:    "QUEUED",
+		if req.Type == "" && len(req.Params) > 0 {
+			data := map[string]any{
+				"message": "Require type with params",
+				"errors":  []orders.FieldError{{Field: "type", Message: "is required when params are given"}},
+			}
+			utils.RenderJsonMessage(data, w, http.StatusBadRequest)
+			return
+		}
+		priority := 0
+		defaultTimeout := config.GetDefaultMissionTimeout()
+		var orderType *orders.OrderType
+		if req.Type != "" {
+			var err error
+			orderType, err = orderTypes.Get(req.Type)
+			if err != nil {
+				data := map[string]any{
+					"message": fmt.Sprintf("Unknown order type %s", req.Type),
+					"errors":  []orders.FieldError{{Field: "type", Message: "is not a registered order type"}},
+				}
+				utils.RenderJsonMessage(data, w, http.StatusBadRequest)
+				return
+			}
+			if fieldErrors := orderType.Validate(req.Params); fieldErrors != nil {
+				invalidParams(w, req.Type, fieldErrors)
+				return
+			}
+			if req.Order == "" {
+				req.Order = orderType.Name
+			}
+			priority = orderType.DefaultPriority
+			if orderType.Timeout() > 0 {
+				defaultTimeout = orderType.Timeout()
+			}
 		}
-		if err := rabbitmq.PublishMission(ch, mission); err != nil {
+		if req.Priority != nil {
+			priority = *req.Priority
+		}
+		if priority < 0 || priority > models.MaxPriority {
 			data := map[string]string{
-				"message": "Failed to publish mission",
+				"message": fmt.Sprintf("priority must be between 0 and %d", models.MaxPriority),
 			}
-			utils.RenderJsonMessage(data, w, http.StatusInternalServerError)
+			utils.RenderJsonMessage(data, w, http.StatusBadRequest)
 			return
+		}
+		if _, _, err := models.ParseTarget(req.Target); err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": err.Error()}, w, http.StatusBadRequest)
+			return
+		}
+		if req.CallbackURL != "" && !validCallbackURL(req.CallbackURL) {
+			utils.RenderJsonMessage(map[string]string{"message": "callback_url must be an absolute http or https URL of a public host"}, w, http.StatusBadRequest)
+			return
+		}
+		capabilities, err := requiredCapabilities(req.Capabilities, orderType)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": err.Error()}, w, http.StatusBadRequest)
+			return
+		}
+		now := time.Now().UTC()
+		executeAt, err := req.executeAt(now)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": err.Error()}, w, http.StatusBadRequest)
+			return
+		}
+		timeout, err := req.timeoutSeconds(defaultTimeout)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": err.Error()}, w, http.StatusBadRequest)
+			return
+		}
+		maxAttempts, backoff, err := req.retryPolicy()
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": err.Error()}, w, http.StatusBadRequest)
+			return
+		}
+		mission := &models.Mission{
+			MissionID:      uuid.New().String(),
+			Order:          req.Order,
+			Type:           req.Type,
+			Params:         req.Params,
+			Target:         req.Target,
+			Capabilities:   capabilities,
+			CallbackURL:    req.CallbackURL,
+			Status:         models.StatusQueued,
+			Priority:       uint8(priority),
+			Attempt:        1,
+			CreatedAt:      now,
+			ExecuteAt:      executeAt,
+			TimeoutSeconds: timeout,
+			MaxAttempts:    maxAttempts,
+			Backoff:        backoff,
+		}
+		if executeAt != nil {
+			mission.Status = models.StatusScheduled
+		}
+
+		if len(capabilities) > 0 && !soldiers.CanServe(mission) {
+			data := map[string]any{
+				"message":      "No registered soldier can serve this mission",
+				"capabilities": capabilities,
+			}
+			utils.RenderJsonMessage(data, w, http.StatusConflict)
+			return
+		}
+
+		key := r.Header.Get(IdempotencyKeyHeader)
+		var record store.IdempotencyRecord
+		if key != "" {
+			record = store.IdempotencyRecord{
+				Key:         key,
+				RequestHash: req.hash(),
+				MissionID:   mission.MissionID,
+				ExpiresAt:   time.Now().Add(ttl),
+			}
+			existing, err := keys.Reserve(record)
+			if err != nil {
+				log.Printf("Failed to reserve idempotency key %q: %v", key, err)
+				utils.RenderJsonMessage(map[string]string{"message": "Failed to check idempotency key"}, w, http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				replayIdempotentResponse(w, existing, record.RequestHash)
+				return
+			}
+		}
+		// fail releases the idempotency key so the client can retry
+		fail := func(message string) {
+			if key != "" {
+				keys.Release(key)
+			}
+			utils.RenderJsonMessage(map[string]string{"message": message}, w, http.StatusInternalServerError)
+		}
+
+		// Store the mission before publishing so a fast soldier's status
+		// update always finds it and is never overwritten back to QUEUED
+		if err := missions.Put(mission); err != nil {
+			log.Printf("Failed to store mission %v: %v", mission.MissionID, err)
+			fail("Failed to store mission")
+			return
+		}
+		rabbitmq.RecordMissionEvent(missions, mission, models.SourceCommander)
+
+		data := map[string]string{"mission_id": mission.MissionID, "status": mission.Status}
+		if executeAt != nil {
+			log.Printf("Mission %v scheduled for %v", mission.MissionID, executeAt)
+			data["execute_at"] = executeAt.Format(time.RFC3339)
 		} else {
-			rabbitmq.SaveMissionStatus(mission.MissionID, mission.Status)
+			if err := rabbitmq.PublishMission(r.Context(), publisher, missions, soldiers, mission); err != nil {
+				log.Printf("Failed to publish mission %v: %v", mission.MissionID, err)
+				rabbitmq.FailUnpublished(missions, mission.MissionID, err)
+				if errors.Is(err, broker.ErrUnroutable) {
+					if key != "" {
+						keys.Release(key)
+					}
+					data := map[string]string{
+						"message":    "No soldier or unit queue is bound for the mission's target",
+						"mission_id": mission.MissionID,
+					}
+					utils.RenderJsonMessage(data, w, http.StatusConflict)
+					return
+				}
+				fail("Failed to publish mission")
+				return
+			}
 			log.Printf("Success: Mission has been published. mission_id: %v ", mission.MissionID)
 		}
-		data := map[string]string{"mission_id": mission.MissionID, "status": "QUEUED"}
+		if key != "" {
+			record.StatusCode = http.StatusAccepted
+			record.Response, _ = json.Marshal(data)
+			if err := keys.Complete(record); err != nil {
+				log.Printf("Failed to save response for idempotency key %q: %v", key, err)
+			}
+		}
 		utils.RenderJsonMessage(data, w, http.StatusAccepted)
 	}
 }
 
+// replayIdempotentResponse answers a repeated request for an already used key
+func replayIdempotentResponse(w http.ResponseWriter, existing *store.IdempotencyRecord, requestHash string) {
+	if existing.RequestHash != requestHash {
+		data := map[string]string{
+			"message": "Idempotency-Key was already used with a different request body",
+		}
+		utils.RenderJsonMessage(data, w, http.StatusUnprocessableEntity)
+		return
+	}
+	if !existing.Completed() {
+		data := map[string]string{
+			"message":    "A request with this Idempotency-Key is still in progress",
+			"mission_id": existing.MissionID,
+		}
+		utils.RenderJsonMessage(data, w, http.StatusConflict)
+		return
+	}
+	log.Printf("Replaying response for mission %v", existing.MissionID)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(existing.StatusCode)
+	w.Write(existing.Response)
+}
+
 // GetMissionHandler returns mission status by ID
-func GetMissionHandler(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Path[len("/missions/"):]
-	store.MissionsMutex.RLock()
-	defer store.MissionsMutex.RUnlock()
+func GetMissionHandler(missions store.MissionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mission, err := missions.Get(r.PathValue("id"))
+		if err == store.ErrNotFound {
+			data := map[string]string{
+				"message": "Mission not found",
+			}
+			utils.RenderJsonMessage(data, w, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			data := map[string]string{
+				"message": "Failed to load mission",
+			}
+			utils.RenderJsonMessage(data, w, http.StatusInternalServerError)
+			return
+		}
+		utils.RenderJsonMessage(mission, w, http.StatusOK)
+	}
+}
 
-	if mission, ok := store.MissionsMap[id]; ok {
-		json.NewEncoder(w).Encode(mission)
-		return
+const (
+	defaultListLimit = 50  // Page size when no limit is given
+	maxListLimit     = 500 // Largest page a client can request
+)
+
+// ListMissionsHandler lists missions filtered by status, priority, creation
+// time and order text, newest first, with cursor-based pagination
+func ListMissionsHandler(missions store.MissionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseMissionFilter(r)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": err.Error()}, w, http.StatusBadRequest)
+			return
+		}
+		page, next, err := missions.List(filter)
+		if err == store.ErrInvalidCursor {
+			utils.RenderJsonMessage(map[string]string{"message": "Invalid cursor"}, w, http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to list missions"}, w, http.StatusInternalServerError)
+			return
+		}
+		data := map[string]any{
+			"missions":    page,
+			"next_cursor": next,
+		}
+		utils.RenderJsonMessage(data, w, http.StatusOK)
+	}
+}
+
+// parseMissionFilter reads list filters from the query string
+func parseMissionFilter(r *http.Request) (store.MissionFilter, error) {
+	q := r.URL.Query()
+	filter := store.MissionFilter{
+		OrderContains: q.Get("order"),
+		Cursor:        q.Get("cursor"),
+		Limit:         defaultListLimit,
+	}
+	if status := q.Get("status"); status != "" {
+		filter.Statuses = strings.Split(strings.ToUpper(status), ",")
+	}
+	if v := q.Get("priority"); v != "" {
+		for _, p := range strings.Split(v, ",") {
+			priority, err := strconv.Atoi(p)
+			if err != nil || priority < 0 || priority > models.MaxPriority {
+				return filter, fmt.Errorf("priority must be between 0 and %d", models.MaxPriority)
+			}
+			filter.Priorities = append(filter.Priorities, uint8(priority))
+		}
+	}
+	if v := q.Get("created_after"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("created_after must be an RFC3339 timestamp")
+		}
+		filter.CreatedAfter = t
+	}
+	if v := q.Get("created_before"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, errors.New("created_before must be an RFC3339 timestamp")
+		}
+		filter.CreatedBefore = t
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxListLimit {
+			return filter, fmt.Errorf("limit must be between 1 and %d", maxListLimit)
+		}
+		filter.Limit = limit
+	}
+	return filter, nil
+}
+
+// CancelMissionHandler cancels a mission. Scheduled and queued missions are
+// marked CANCELLED right away; in-flight missions are flagged and stay
+// IN_PROGRESS until the soldier aborts and reports CANCELLED. Soldiers are
+// signalled in both cases.
+func CancelMissionHandler(publisher broker.Broker, missions store.MissionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		mission, err := missions.Get(id)
+		if err == store.ErrNotFound {
+			utils.RenderJsonMessage(map[string]string{"message": "Mission not found"}, w, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to load mission"}, w, http.StatusInternalServerError)
+			return
+		}
+		if models.IsTerminal(mission.Status) {
+			data := map[string]string{
+				"message":    "Mission has already finished",
+				"mission_id": id,
+				"status":     mission.Status,
+			}
+			utils.RenderJsonMessage(data, w, http.StatusConflict)
+			return
+		}
+
+		if mission.Status == models.StatusScheduled || mission.Status == models.StatusQueued || mission.Status == models.StatusRetryPending {
+			cancelled := models.StatusUpdate{MissionID: id, Status: models.StatusCancelled}
+			if err := rabbitmq.SaveMissionStatus(missions, cancelled, models.SourceCommander); err != nil {
+				utils.RenderJsonMessage(map[string]string{"message": "Failed to cancel mission"}, w, http.StatusConflict)
+				return
+			}
+			// Soldiers skip cancelled missions on receipt; the commander rejects any late
+			// status for them, so a lost signal only costs wasted work. Scheduled missions
+			// are signalled too in case the scheduler published them meanwhile.
+			if err := rabbitmq.PublishCancel(publisher, id); err != nil {
+				log.Printf("Failed to broadcast cancel for queued mission %v: %v", id, err)
+			}
+			data := map[string]string{"mission_id": id, "status": models.StatusCancelled}
+			utils.RenderJsonMessage(data, w, http.StatusOK)
+			return
+		}
+
+		err = missions.Update(id, func(m *models.Mission) error {
+			m.CancelRequested = true
+			return nil
+		})
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to cancel mission"}, w, http.StatusInternalServerError)
+			return
+		}
+		if err := rabbitmq.PublishCancel(publisher, id); err != nil {
+			log.Printf("Failed to broadcast cancel for mission %v: %v", id, err)
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to signal cancellation"}, w, http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Cancellation requested for in-flight mission %v", id)
+		data := map[string]string{
+			"message":    "Cancellation requested",
+			"mission_id": id,
+			"status":     mission.Status,
+		}
+		utils.RenderJsonMessage(data, w, http.StatusAccepted)
 	}
-	data := map[string]string{
-		"message": "Mission not found",
+}
+
+// missionEvent is a status transition plus the time spent in that status
+type missionEvent struct {
+	models.MissionEvent
+	DurationMS *int64 `json:"duration_ms,omitempty"`
+}
+
+// GetMissionEventsHandler returns the ordered status history of a mission
+func GetMissionEventsHandler(missions store.MissionStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, err := missions.Get(id); err != nil {
+			if err == store.ErrNotFound {
+				utils.RenderJsonMessage(map[string]string{"message": "Mission not found"}, w, http.StatusNotFound)
+				return
+			}
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to load mission"}, w, http.StatusInternalServerError)
+			return
+		}
+		history, err := missions.Events(id)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to load mission events"}, w, http.StatusInternalServerError)
+			return
+		}
+		// Each phase lasts until the next transition; the current phase has no duration yet
+		events := make([]missionEvent, len(history))
+		for i, e := range history {
+			events[i].MissionEvent = e
+			if i+1 < len(history) {
+				d := history[i+1].Timestamp.Sub(e.Timestamp).Milliseconds()
+				events[i].DurationMS = &d
+			}
+		}
+		data := map[string]any{
+			"mission_id": id,
+			"events":     events,
+		}
+		utils.RenderJsonMessage(data, w, http.StatusOK)
+	}
+}
+
+// GetMissionWebhooksHandler returns every webhook delivery attempt of a mission
+func GetMissionWebhooksHandler(missions store.MissionStore, deliveries store.DeliveryStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		if _, err := missions.Get(id); err != nil {
+			if err == store.ErrNotFound {
+				utils.RenderJsonMessage(map[string]string{"message": "Mission not found"}, w, http.StatusNotFound)
+				return
+			}
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to load mission"}, w, http.StatusInternalServerError)
+			return
+		}
+		attempts, err := deliveries.Deliveries(id)
+		if err != nil {
+			utils.RenderJsonMessage(map[string]string{"message": "Failed to load webhook deliveries"}, w, http.StatusInternalServerError)
+			return
+		}
+		data := map[string]any{
+			"mission_id": id,
+			"deliveries": attempts,
+		}
+		utils.RenderJsonMessage(data, w, http.StatusOK)
 	}
-	utils.RenderJsonMessage(data, w, http.StatusNotFound)
 }
 
 // App health check
@@ -2,32 +2,40 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"mission_control/broker"
 	"mission_control/commander/models"
+	"mission_control/commander/orders"
+	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
 	"mission_control/commander/store"
+
+	amqp "github.com/rabbitmq/amqp091-go"
 )
 
 func TestGetMissionHandler_Success(t *testing.T) {
-	// Insert mission into global map
+	// Insert mission into the store
+	missions := store.NewMemoryStore()
 	mission := &models.Mission{
-		MissionID:     "abc123",
-		Order:  "Recon",
-		Status: "QUEUED",
+		MissionID: "abc123",
+		Order:     "Recon",
+		Status:    "QUEUED",
 	}
-
-	store.MissionsMutex.Lock()
-	store.MissionsMap["abc123"] = mission
-	store.MissionsMutex.Unlock()
+	missions.Put(mission)
 
 	// Create request
 	req := httptest.NewRequest("GET", "/missions/abc123", nil)
+	req.SetPathValue("id", "abc123")
 	rr := httptest.NewRecorder()
 
 	// Call handler
-	GetMissionHandler(rr, req)
+	GetMissionHandler(missions)(rr, req)
 
 	// Expectations
 	if rr.Code != http.StatusOK {
@@ -50,16 +58,618 @@ func TestGetMissionHandler_Success(t *testing.T) {
 
 func TestGetMissionHandler_NotFound(t *testing.T) {
 	req := httptest.NewRequest("GET", "/missions/unknown", nil)
+	req.SetPathValue("id", "unknown")
 	rr := httptest.NewRecorder()
 
-	GetMissionHandler(rr, req)
+	GetMissionHandler(store.NewMemoryStore())(rr, req)
 
 	if rr.Code != http.StatusNotFound {
 		t.Fatalf("expected 404, got %d", rr.Code)
 	}
 
-	expected := "Mission not found\n"
+	expected := "{\"message\":\"Mission not found\"}\n"
 	if rr.Body.String() != expected {
 		t.Fatalf("expected body %q, got %q", expected, rr.Body.String())
 	}
 }
+
+func TestGetMissionEventsHandler(t *testing.T) {
+	missions := store.NewMemoryStore()
+	mission := &models.Mission{MissionID: "abc123", Order: "Recon", Status: "QUEUED"}
+	missions.Put(mission)
+	rabbitmq.RecordMissionEvent(missions, mission, models.SourceCommander)
+	rabbitmq.SaveMissionStatus(missions, models.StatusUpdate{MissionID: "abc123", Status: "IN_PROGRESS", Seq: 1}, models.SourceSoldier)
+	rabbitmq.SaveMissionStatus(missions, models.StatusUpdate{MissionID: "abc123", Status: "COMPLETED", Seq: 2}, models.SourceSoldier)
+
+	req := httptest.NewRequest("GET", "/missions/abc123/events", nil)
+	req.SetPathValue("id", "abc123")
+	rr := httptest.NewRecorder()
+
+	GetMissionEventsHandler(missions)(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200 OK, got %d", rr.Code)
+	}
+
+	var resp struct {
+		Events []struct {
+			Status     string `json:"status"`
+			Source     string `json:"source"`
+			DurationMS *int64 `json:"duration_ms"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	expected := []string{"QUEUED", "IN_PROGRESS", "COMPLETED"}
+	if len(resp.Events) != len(expected) {
+		t.Fatalf("expected %d events, got %d", len(expected), len(resp.Events))
+	}
+	for i, status := range expected {
+		if resp.Events[i].Status != status {
+			t.Fatalf("event %d: expected %s, got %s", i, status, resp.Events[i].Status)
+		}
+	}
+	if resp.Events[0].Source != models.SourceCommander || resp.Events[1].Source != models.SourceSoldier {
+		t.Fatalf("unexpected event sources: %+v", resp.Events)
+	}
+	if resp.Events[0].DurationMS == nil || resp.Events[2].DurationMS != nil {
+		t.Fatalf("expected durations for finished phases only: %+v", resp.Events)
+	}
+}
+
+func TestGetMissionEventsHandler_NotFound(t *testing.T) {
+	req := httptest.NewRequest("GET", "/missions/unknown/events", nil)
+	req.SetPathValue("id", "unknown")
+	rr := httptest.NewRecorder()
+
+	GetMissionEventsHandler(store.NewMemoryStore())(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestListMissionsHandler(t *testing.T) {
+	missions := store.NewMemoryStore()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, status := range []string{"QUEUED", "FAILED", "QUEUED"} {
+		missions.Put(&models.Mission{
+			MissionID: fmt.Sprintf("m%d", i),
+			Order:     "Recon",
+			Status:    status,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		})
+	}
+
+	req := httptest.NewRequest("GET", "/missions?status=queued&limit=1", nil)
+	rr := httptest.NewRecorder()
+
+	ListMissionsHandler(missions)(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200 OK, got %d", rr.Code)
+	}
+	var resp struct {
+		Missions   []models.Mission `json:"missions"`
+		NextCursor string           `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Missions) != 1 || resp.Missions[0].MissionID != "m2" {
+		t.Fatalf("expected newest queued mission m2, got %+v", resp.Missions)
+	}
+	if resp.NextCursor == "" {
+		t.Fatalf("expected a next cursor")
+	}
+
+	// Follow the cursor to the last queued mission
+	req = httptest.NewRequest("GET", "/missions?status=QUEUED&limit=1&cursor="+resp.NextCursor, nil)
+	rr = httptest.NewRecorder()
+	ListMissionsHandler(missions)(rr, req)
+
+	resp.NextCursor = ""
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if len(resp.Missions) != 1 || resp.Missions[0].MissionID != "m0" || resp.NextCursor != "" {
+		t.Fatalf("unexpected second page: %+v", resp)
+	}
+}
+
+func TestListMissionsHandler_BadRequest(t *testing.T) {
+	for _, query := range []string{"created_after=yesterday", "limit=0", "limit=abc", "cursor=%25%25", "priority=10"} {
+		req := httptest.NewRequest("GET", "/missions?"+query, nil)
+		rr := httptest.NewRecorder()
+
+		ListMissionsHandler(store.NewMemoryStore())(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, rr.Code)
+		}
+	}
+}
+
+func TestCreateMissionHandler_IdempotencyKey(t *testing.T) {
+	missions := store.NewMemoryStore()
+	body := `{"order":"Recon"}`
+
+	// Simulate a first request that already completed
+	missions.Reserve(store.IdempotencyRecord{
+		Key:         "retry-1",
+		RequestHash: createMissionRequest{Order: "Recon"}.hash(),
+		MissionID:   "abc123",
+		StatusCode:  http.StatusAccepted,
+		Response:    []byte(`{"mission_id":"abc123","status":"QUEUED"}`),
+		ExpiresAt:   time.Now().Add(time.Hour),
+	})
+
+	// Repeats are answered from the stored response without publishing (nil channel)
+	req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, "retry-1")
+	rr := httptest.NewRecorder()
+
+	CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+	if rr.Body.String() != `{"mission_id":"abc123","status":"QUEUED"}` {
+		t.Fatalf("expected replayed body, got %s", rr.Body.String())
+	}
+	if rr.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected Idempotent-Replayed header")
+	}
+
+	// The same key with a different body is rejected
+	req = httptest.NewRequest("POST", "/missions", strings.NewReader(`{"order":"Attack"}`))
+	req.Header.Set(IdempotencyKeyHeader, "retry-1")
+	rr = httptest.NewRecorder()
+
+	CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d", rr.Code)
+	}
+}
+
+// blockingBroker holds publishes until release is closed
+type blockingBroker struct {
+	broker.Broker
+	published chan struct{}
+	release   chan struct{}
+}
+
+func (b *blockingBroker) Publish(exchange, key string, mandatory bool, msg amqp.Publishing) error {
+	b.published <- struct{}{}
+	<-b.release
+	return b.Broker.Publish(exchange, key, mandatory, msg)
+}
+
+func TestCreateMissionHandler_IdempotencyKeyPublishesOnce(t *testing.T) {
+	messages := broker.NewMemory()
+	messages.Declare(rabbitmq.Topology())
+	publisher := &blockingBroker{Broker: messages, published: make(chan struct{}, 1), release: make(chan struct{})}
+	missions := store.NewMemoryStore()
+	create := CreateMissionHandler(publisher, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(`{"order":"Recon"}`))
+		req.Header.Set(IdempotencyKeyHeader, "retry-1")
+		rr := httptest.NewRecorder()
+		create(rr, req)
+		return rr
+	}
+
+	// The first request reserves the key and is held while publishing
+	first := make(chan *httptest.ResponseRecorder)
+	go func() { first <- post() }()
+	<-publisher.published
+
+	// A duplicate arriving meanwhile does not publish again
+	if rr := post(); rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409 while the first request is in progress, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	close(publisher.release)
+	rr := <-first
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	// A retry after completion replays the stored response
+	replay := post()
+	var created, replayed map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	json.Unmarshal(replay.Body.Bytes(), &replayed)
+	if replay.Code != http.StatusAccepted || replayed["mission_id"] != created["mission_id"] || replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("expected the first response replayed, got %d: %s", replay.Code, replay.Body.String())
+	}
+	if n := messages.Len(rabbitmq.OrdersQueue); n != 1 {
+		t.Fatalf("expected exactly one published order, got %d", n)
+	}
+}
+
+func TestCancelMissionHandler_FinishedOrUnknown(t *testing.T) {
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "done", Order: "Recon", Status: models.StatusCompleted})
+
+	cases := map[string]int{
+		"done":    http.StatusConflict,
+		"unknown": http.StatusNotFound,
+	}
+	for id, expected := range cases {
+		req := httptest.NewRequest("POST", "/missions/"+id+"/cancel", nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		// Neither case may signal soldiers, so no channel is needed
+		CancelMissionHandler(nil, missions)(rr, req)
+
+		if rr.Code != expected {
+			t.Fatalf("%s: expected %d, got %d", id, expected, rr.Code)
+		}
+	}
+
+	if got, _ := missions.Get("done"); got.Status != models.StatusCompleted {
+		t.Fatalf("finished mission must not change, got %s", got.Status)
+	}
+}
+
+// controlBroker returns a memory broker with a queue receiving control messages
+func controlBroker(t *testing.T) *broker.Memory {
+	t.Helper()
+	messages := broker.NewMemory()
+	err := messages.Declare(broker.Topology{
+		Exchanges: []broker.Exchange{{Name: rabbitmq.ControlExchange, Kind: broker.Fanout}},
+		Queues:    []broker.Queue{{Name: "control"}},
+		Bindings:  []broker.Binding{{Queue: "control", Exchange: rabbitmq.ControlExchange}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return messages
+}
+
+// cancelMission calls CancelMissionHandler for the mission
+func cancelMission(publisher broker.Broker, missions store.MissionStore, id string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("POST", "/missions/"+id+"/cancel", nil)
+	req.SetPathValue("id", id)
+	rr := httptest.NewRecorder()
+	CancelMissionHandler(publisher, missions)(rr, req)
+	return rr
+}
+
+func TestCancelMissionHandler_Queued(t *testing.T) {
+	messages := controlBroker(t)
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Order: "Recon", Status: models.StatusQueued})
+
+	rr := cancelMission(messages, missions, "m1")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if got, _ := missions.Get("m1"); got.Status != models.StatusCancelled {
+		t.Fatalf("expected CANCELLED, got %s", got.Status)
+	}
+	// Soldiers are signalled in case one already received the order
+	if messages.Len("control") != 1 {
+		t.Fatalf("expected a cancel control message, got %d", messages.Len("control"))
+	}
+}
+
+func TestCancelMissionHandler_InProgress(t *testing.T) {
+	messages := controlBroker(t)
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Order: "Recon", Status: models.StatusInProgress})
+
+	rr := cancelMission(messages, missions, "m1")
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	// The mission stays IN_PROGRESS until the soldier reports CANCELLED
+	if got, _ := missions.Get("m1"); got.Status != models.StatusInProgress || !got.CancelRequested {
+		t.Fatalf("expected an IN_PROGRESS mission flagged for cancellation, got %+v", got)
+	}
+	d, ok := messages.Get("control")
+	var msg models.ControlMessage
+	json.Unmarshal(d.Body, &msg)
+	if !ok || msg.Type != models.ControlCancel || msg.MissionID != "m1" {
+		t.Fatalf("expected a cancel control message for m1, got %s", d.Body)
+	}
+}
+
+func TestCancelMissionHandler_PublishFails(t *testing.T) {
+	// Without a declared control exchange every publish fails
+	messages := broker.NewMemory()
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "running", Order: "Recon", Status: models.StatusInProgress})
+	missions.Put(&models.Mission{MissionID: "queued", Order: "Recon", Status: models.StatusQueued})
+
+	if rr := cancelMission(messages, missions, "running"); rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when soldiers cannot be signalled, got %d", rr.Code)
+	}
+	// Queued missions are cancelled by the commander alone
+	if rr := cancelMission(messages, missions, "queued"); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a queued mission, got %d", rr.Code)
+	}
+	if got, _ := missions.Get("queued"); got.Status != models.StatusCancelled {
+		t.Fatalf("expected CANCELLED, got %s", got.Status)
+	}
+}
+
+func TestCreateMissionHandler_InvalidPriorityOrTarget(t *testing.T) {
+	for _, body := range []string{
+		`{"order":"Recon","priority":10}`,
+		`{"order":"Recon","priority":-1}`,
+		`{"order":"Recon","target":"unit:*"}`,
+		`{"order":"Recon","target":"alpha"}`,
+	} {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		missions := store.NewMemoryStore()
+		CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestCreateMissionHandler_Scheduled(t *testing.T) {
+	missions := store.NewMemoryStore()
+	req := httptest.NewRequest("POST", "/missions", strings.NewReader(`{"order":"Recon","delay":"1h"}`))
+	rr := httptest.NewRecorder()
+
+	// Scheduled missions are not published yet, so no channel is needed
+	CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d", rr.Code)
+	}
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if resp["status"] != models.StatusScheduled || resp["execute_at"] == "" {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+
+	mission, err := missions.Get(resp["mission_id"])
+	if err != nil {
+		t.Fatalf("expected stored mission: %v", err)
+	}
+	if mission.ExecuteAt == nil || time.Until(*mission.ExecuteAt) < 59*time.Minute {
+		t.Fatalf("expected execute_at about one hour ahead, got %v", mission.ExecuteAt)
+	}
+}
+
+func TestCreateMissionHandler_InvalidSchedule(t *testing.T) {
+	for _, body := range []string{
+		`{"order":"Recon","delay":"soon"}`,
+		`{"order":"Recon","delay":"-5m"}`,
+		`{"order":"Recon","delay":"5m","execute_at":"2030-01-01T00:00:00Z"}`,
+	} {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		missions := store.NewMemoryStore()
+		CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestCreateMissionHandler_TypedOrder(t *testing.T) {
+	missions := store.NewMemoryStore()
+	body := `{"type":"strike","params":{"target":"bridge","munitions":2},"delay":"1h"}`
+	req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+	rr := httptest.NewRecorder()
+
+	CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	mission, err := missions.Get(resp["mission_id"])
+	if err != nil {
+		t.Fatalf("expected stored mission: %v", err)
+	}
+
+	// The order type supplies the order text, priority and timeout defaults
+	if mission.Type != "strike" || mission.Order != "strike" {
+		t.Fatalf("expected strike order, got type %q order %q", mission.Type, mission.Order)
+	}
+	if mission.Priority != 8 || mission.TimeoutSeconds != 300 {
+		t.Fatalf("expected type defaults, got priority %d timeout %d", mission.Priority, mission.TimeoutSeconds)
+	}
+	if string(mission.Params) != `{"target":"bridge","munitions":2}` {
+		t.Fatalf("expected params to be kept, got %s", mission.Params)
+	}
+}
+
+func TestCreateMissionHandler_InvalidParams(t *testing.T) {
+	cases := map[string]string{
+		`{"type":"strike","params":{"munitions":0}}`: "params.target",
+		`{"type":"unknown","params":{}}`:             "type",
+		`{"order":"Recon","params":{"target":"x"}}`:  "type",
+	}
+	for body, field := range cases {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		missions := store.NewMemoryStore()
+		CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rr.Code)
+		}
+		var resp struct {
+			Errors []orders.FieldError `json:"errors"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &resp)
+		if len(resp.Errors) == 0 || resp.Errors[0].Field != field {
+			t.Fatalf("%s: expected error on %s, got %+v", body, field, resp.Errors)
+		}
+		if page, _, _ := missions.List(store.MissionFilter{}); len(page) != 0 {
+			t.Fatalf("%s: invalid mission must not be stored", body)
+		}
+	}
+}
+
+func TestCreateMissionHandler_Capabilities(t *testing.T) {
+	soldiers := registry.New(time.Minute, time.Hour)
+	soldiers.Record(models.Heartbeat{SoldierID: "s1", Unit: "alpha", Capabilities: []string{"night-vision", "recon"}}, time.Now().UTC())
+
+	cases := map[string]int{
+		`{"order":"Recon","capabilities":["Night-Vision"],"delay":"1h"}`:                http.StatusAccepted,
+		`{"order":"Recon","capabilities":["night-vision"],"target":"unit:bravo"}`:       http.StatusConflict,
+		`{"order":"Strike","capabilities":["strike"]}`:                                  http.StatusConflict,
+		`{"order":"Recon","capabilities":[" "]}`:                                        http.StatusBadRequest,
+		`{"order":"Recon","capabilities":["recon"],"target":"soldier:s1","delay":"1h"}`: http.StatusAccepted,
+	}
+	for body, expected := range cases {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		// Accepted missions are scheduled, so no channel is needed
+		missions := store.NewMemoryStore()
+		CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), soldiers)(rr, req)
+
+		if rr.Code != expected {
+			t.Fatalf("%s: expected %d, got %d: %s", body, expected, rr.Code, rr.Body.String())
+		}
+		if expected == http.StatusAccepted {
+			var resp map[string]string
+			json.Unmarshal(rr.Body.Bytes(), &resp)
+			mission, _ := missions.Get(resp["mission_id"])
+			if len(mission.Capabilities) != 1 || mission.Capabilities[0] != strings.ToLower(mission.Capabilities[0]) {
+				t.Fatalf("%s: expected normalised capabilities, got %v", body, mission.Capabilities)
+			}
+		}
+	}
+}
+
+func TestCreateMissionHandler_InvalidCallbackURL(t *testing.T) {
+	for _, body := range []string{
+		`{"order":"Recon","callback_url":"ftp://example.com/hook"}`,
+		`{"order":"Recon","callback_url":"/hook"}`,
+		`{"order":"Recon","callback_url":"http://"}`,
+		`{"order":"Recon","callback_url":"http://169.254.169.254/latest/meta-data"}`,
+		`{"order":"Recon","callback_url":"http://127.0.0.1:8080/hook"}`,
+		`{"order":"Recon","callback_url":"https://[::1]/hook"}`,
+		`{"order":"Recon","callback_url":"http://10.0.0.5/hook"}`,
+		`{"order":"Recon","callback_url":"http://localhost/hook"}`,
+	} {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		missions := store.NewMemoryStore()
+		CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+}
+
+func TestGetMissionWebhooksHandler(t *testing.T) {
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "abc123", Order: "Recon", Status: "QUEUED"})
+	missions.AddDelivery(models.WebhookDelivery{MissionID: "abc123", EventID: 1, URL: "http://example.com/hook", Status: "QUEUED", Attempt: 1, StatusCode: 500})
+	missions.AddDelivery(models.WebhookDelivery{MissionID: "abc123", EventID: 1, URL: "http://example.com/hook", Status: "QUEUED", Attempt: 2, StatusCode: 200, Delivered: true})
+
+	req := httptest.NewRequest("GET", "/missions/abc123/webhooks", nil)
+	req.SetPathValue("id", "abc123")
+	rr := httptest.NewRecorder()
+
+	GetMissionWebhooksHandler(missions, missions)(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200 OK, got %d", rr.Code)
+	}
+	var resp struct {
+		Deliveries []models.WebhookDelivery `json:"deliveries"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Deliveries) != 2 || resp.Deliveries[0].Delivered || !resp.Deliveries[1].Delivered {
+		t.Fatalf("expected a failed then a delivered attempt, got %+v", resp.Deliveries)
+	}
+
+	req = httptest.NewRequest("GET", "/missions/unknown/webhooks", nil)
+	req.SetPathValue("id", "unknown")
+	rr = httptest.NewRecorder()
+	GetMissionWebhooksHandler(missions, missions)(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rr.Code)
+	}
+}
+
+func TestCreateMissionHandler_RetryPolicy(t *testing.T) {
+	for _, body := range []string{
+		`{"order":"Recon","max_attempts":0}`,
+		`{"order":"Recon","max_attempts":11}`,
+		`{"order":"Recon","max_attempts":3,"backoff":{"type":"linear"}}`,
+		`{"order":"Recon","max_attempts":3,"backoff":{"initial":"10s","max":"5s"}}`,
+	} {
+		req := httptest.NewRequest("POST", "/missions", strings.NewReader(body))
+		rr := httptest.NewRecorder()
+
+		missions := store.NewMemoryStore()
+		CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", body, rr.Code)
+		}
+	}
+
+	missions := store.NewMemoryStore()
+	req := httptest.NewRequest("POST", "/missions", strings.NewReader(`{"order":"Recon","delay":"1h","max_attempts":3,"backoff":{"type":"fixed","initial":"10s"}}`))
+	rr := httptest.NewRecorder()
+	CreateMissionHandler(nil, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))(rr, req)
+
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	mission, err := missions.Get(resp["mission_id"])
+	if err != nil {
+		t.Fatalf("expected mission to be stored, got %v", err)
+	}
+	if mission.MaxAttempts != 3 || mission.Backoff == nil || mission.Backoff.Type != models.BackoffFixed || mission.Backoff.InitialSeconds != 10 {
+		t.Fatalf("expected retry policy to be stored, got %d %+v", mission.MaxAttempts, mission.Backoff)
+	}
+}
+
+func TestCreateMissionHandler_MemoryBroker(t *testing.T) {
+	messages := broker.NewMemory()
+	messages.Declare(rabbitmq.Topology())
+	missions := store.NewMemoryStore()
+	create := CreateMissionHandler(messages, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour))
+
+	rr := httptest.NewRecorder()
+	create(rr, httptest.NewRequest("POST", "/missions", strings.NewReader(`{"order":"Recon","priority":8}`)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	d, ok := messages.Get(rabbitmq.OrdersQueue)
+	var published models.Mission
+	json.Unmarshal(d.Body, &published)
+	if !ok || published.Order != "Recon" || d.Priority != 8 {
+		t.Fatalf("expected the mission in the orders queue, got %+v", d)
+	}
+
+	// Nothing is bound for soldier s1, so the broker returns the order
+	rr = httptest.NewRecorder()
+	create(rr, httptest.NewRequest("POST", "/missions", strings.NewReader(`{"order":"Recon","target":"soldier:s1"}`)))
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if mission, err := missions.Get(resp["mission_id"]); err != nil || mission.Status != models.StatusFailed {
+		t.Fatalf("expected the unroutable mission to be FAILED, got %+v %v", mission, err)
+	}
+}
@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"net/http"
+
+	"mission_control/commander/orders"
+	"mission_control/commander/utils"
+)
+
+// ListOrderTypesHandler lists the order types POST /missions accepts, with their params schema and defaults
+func ListOrderTypesHandler(registry *orders.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]any{
+			"order_types": registry.List(),
+		}
+		utils.RenderJsonMessage(data, w, http.StatusOK)
+	}
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"mission_control/commander/registry"
+	"mission_control/commander/utils"
+)
+
+// ListSoldiersHandler lists registered soldiers with their state and current missions
+func ListSoldiersHandler(soldiers *registry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := map[string]any{
+			"soldiers": soldiers.List(time.Now().UTC()),
+		}
+		utils.RenderJsonMessage(data, w, http.StatusOK)
+	}
+}
+
+// GetSoldierHandler returns a registered soldier by ID
+func GetSoldierHandler(soldiers *registry.Registry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		soldier, err := soldiers.Get(r.PathValue("id"), time.Now().UTC())
+		if err == registry.ErrNotFound {
+			data := map[string]string{
+				"message": "Soldier not found",
+			}
+			utils.RenderJsonMessage(data, w, http.StatusNotFound)
+			return
+		}
+		utils.RenderJsonMessage(soldier, w, http.StatusOK)
+	}
+}
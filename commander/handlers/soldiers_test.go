@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"mission_control/commander/models"
+	"mission_control/commander/registry"
+)
+
+func TestSoldierHandlers(t *testing.T) {
+	soldiers := registry.New(15*time.Second, time.Minute)
+	soldiers.Record(models.Heartbeat{Type: models.HeartbeatRegister, SoldierID: "s1", Missions: []string{"m1"}}, time.Now().UTC())
+
+	rr := httptest.NewRecorder()
+	ListSoldiersHandler(soldiers)(rr, httptest.NewRequest("GET", "/soldiers", nil))
+
+	var resp struct {
+		Soldiers []models.Soldier `json:"soldiers"`
+	}
+	json.Unmarshal(rr.Body.Bytes(), &resp)
+	if rr.Code != http.StatusOK || len(resp.Soldiers) != 1 || resp.Soldiers[0].State != models.SoldierOnline {
+		t.Fatalf("expected one ONLINE soldier, got %d %s", rr.Code, rr.Body.String())
+	}
+
+	for id, expected := range map[string]int{"s1": http.StatusOK, "unknown": http.StatusNotFound} {
+		req := httptest.NewRequest("GET", "/soldiers/"+id, nil)
+		req.SetPathValue("id", id)
+		rr := httptest.NewRecorder()
+
+		GetSoldierHandler(soldiers)(rr, req)
+
+		if rr.Code != expected {
+			t.Fatalf("%s: expected %d, got %d", id, expected, rr.Code)
+		}
+	}
+}
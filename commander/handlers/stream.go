@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mission_control/commander/hub"
+	"mission_control/commander/store"
+	"mission_control/commander/utils"
+)
+
+// keepAliveInterval is how often an idle stream sends a comment so proxies keep it open
+const keepAliveInterval = 15 * time.Second
+
+// StreamMissionHandler streams the status transitions of one mission as
+// Server-Sent Events. New clients first get a snapshot of the mission;
+// reconnecting clients sending Last-Event-ID get the events they missed, or
+// a fresh snapshot if those are no longer remembered.
+func StreamMissionHandler(missions store.MissionStore, events *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		missionID := r.PathValue("id")
+		if _, err := missions.Get(missionID); err != nil {
+			data := map[string]string{
+				"message": "Mission not found",
+			}
+			utils.RenderJsonMessage(data, w, http.StatusNotFound)
+			return
+		}
+		match := func(e hub.Event) bool { return e.MissionID == missionID }
+		sub, replay, complete := events.Subscribe(match, lastEventID(r))
+		defer sub.Close()
+
+		// Take the snapshot after subscribing so no transition falls in between
+		var snapshot func() error
+		if lastEventID(r) == 0 || !complete {
+			snapshot = func() error {
+				mission, err := missions.Get(missionID)
+				if err != nil {
+					return err
+				}
+				return writeEvent(w, sub.LastID, "snapshot", mission)
+			}
+		}
+		streamEvents(w, r, sub, replay, snapshot)
+	}
+}
+
+// StreamMissionsHandler streams the status transitions of all missions as
+// Server-Sent Events, optionally only those entering one of the statuses in
+// the comma-separated status parameter. Reconnecting clients sending
+// Last-Event-ID get the events they missed, or a resync event if those are
+// no longer remembered and missions should be listed again.
+func StreamMissionsHandler(events *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var statuses []string
+		if status := r.URL.Query().Get("status"); status != "" {
+			statuses = strings.Split(strings.ToUpper(status), ",")
+		}
+		match := func(e hub.Event) bool { return len(statuses) == 0 || slices.Contains(statuses, e.Status) }
+		sub, replay, complete := events.Subscribe(match, lastEventID(r))
+		defer sub.Close()
+
+		var resync func() error
+		if !complete {
+			resync = func() error {
+				return writeEvent(w, sub.LastID, "resync", map[string]string{"message": "Events were missed, list missions again"})
+			}
+		}
+		streamEvents(w, r, sub, replay, resync)
+	}
+}
+
+// streamEvents writes the SSE headers, the optional first event, the
+// replayed events and then live events until the client goes away or the
+// subscription is dropped
+func streamEvents(w http.ResponseWriter, r *http.Request, sub *hub.Subscription, replay []hub.Event, first func() error) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		utils.RenderJsonMessage(map[string]string{"message": "Streaming not supported"}, w, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	if first != nil {
+		if err := first(); err != nil {
+			return
+		}
+	}
+	for _, e := range replay {
+		if err := writeEvent(w, e.ID, "status", e); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				// Dropped for falling behind; the client reconnects with Last-Event-ID
+				return
+			}
+			if err := writeEvent(w, e.ID, "status", e); err != nil {
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload
+func writeEvent(w http.ResponseWriter, id uint64, event string, data any) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, body)
+	return err
+}
+
+// lastEventID returns the Last-Event-ID sent by a reconnecting client, 0 if none
+func lastEventID(r *http.Request) uint64 {
+	id, _ := strconv.ParseUint(r.Header.Get("Last-Event-ID"), 10, 64)
+	return id
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"mission_control/commander/hub"
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+// sseEvent is a parsed Server-Sent Event
+type sseEvent struct {
+	id, event, data string
+}
+
+// readEvents reads n events from an SSE response body
+func readEvents(t *testing.T, scanner *bufio.Scanner, n int) []sseEvent {
+	t.Helper()
+	var events []sseEvent
+	var current sseEvent
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.event != "" {
+				events = append(events, current)
+			}
+			current = sseEvent{}
+		case strings.HasPrefix(line, "id: "):
+			current.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			current.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			current.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	if len(events) < n {
+		t.Fatalf("expected %d events, got %d", n, len(events))
+	}
+	return events
+}
+
+// openStream starts a stream request and returns a scanner over its body
+func openStream(t *testing.T, url, lastEventID string) *bufio.Scanner {
+	t.Helper()
+	req, _ := http.NewRequest("GET", url, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to open stream: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	return bufio.NewScanner(resp.Body)
+}
+
+func TestStreamMissionHandler(t *testing.T) {
+	events := hub.New(100)
+	missions := events.Wrap(store.NewMemoryStore())
+	missions.Put(&models.Mission{MissionID: "m1", Order: "Recon", Status: models.StatusQueued, Attempt: 1})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /missions/{id}/stream", StreamMissionHandler(missions, events))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close) // Runs after the streams opened below are closed
+
+	// New clients start with a snapshot, then get live transitions
+	stream := openStream(t, server.URL+"/missions/m1/stream", "")
+	if got := readEvents(t, stream, 1)[0]; got.event != "snapshot" || !strings.Contains(got.data, `"status":"QUEUED"`) {
+		t.Fatalf("expected a QUEUED snapshot, got %+v", got)
+	}
+	missions.AddEvent(models.MissionEvent{MissionID: "m2", Status: models.StatusQueued})
+	missions.AddEvent(models.MissionEvent{MissionID: "m1", Status: models.StatusInProgress})
+	missions.AddEvent(models.MissionEvent{MissionID: "m1", Status: models.StatusCompleted})
+	live := readEvents(t, stream, 2)
+	if live[0].event != "status" || !strings.Contains(live[0].data, `"status":"IN_PROGRESS"`) || !strings.Contains(live[1].data, `"status":"COMPLETED"`) {
+		t.Fatalf("expected m1 transitions only, got %+v", live)
+	}
+
+	// Reconnecting after IN_PROGRESS replays only what was missed
+	resumed := openStream(t, server.URL+"/missions/m1/stream", live[0].id)
+	if got := readEvents(t, resumed, 1)[0]; got.id != live[1].id || got.event != "status" {
+		t.Fatalf("expected COMPLETED to be replayed, got %+v", got)
+	}
+
+	// Unknown event IDs fall back to a snapshot
+	stale := openStream(t, server.URL+"/missions/m1/stream", "1")
+	if got := readEvents(t, stale, 1)[0]; got.event != "snapshot" {
+		t.Fatalf("expected a snapshot for a stale Last-Event-ID, got %+v", got)
+	}
+}
+
+func TestStreamMissionsHandler_StatusFilter(t *testing.T) {
+	events := hub.New(100)
+	server := httptest.NewServer(StreamMissionsHandler(events))
+	t.Cleanup(server.Close) // Runs after the stream opened below is closed
+
+	stream := openStream(t, server.URL+"/missions/stream?status=failed,cancelled", "")
+
+	// The response headers are only sent once the handler has subscribed
+	events.Publish(models.MissionEvent{MissionID: "m1", Status: models.StatusFailed})
+	if got := readEvents(t, stream, 1)[0]; !strings.Contains(got.data, `"mission_id":"m1"`) {
+		t.Fatalf("expected the FAILED event, got %+v", got)
+	}
+	events.Publish(models.MissionEvent{MissionID: "m2", Status: models.StatusCompleted})
+	events.Publish(models.MissionEvent{MissionID: "m3", Status: models.StatusCancelled})
+
+	if got := readEvents(t, stream, 1)[0]; !strings.Contains(got.data, `"mission_id":"m3"`) {
+		t.Fatalf("expected only the CANCELLED event, got %+v", got)
+	}
+}
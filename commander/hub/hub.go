@@ -0,0 +1,138 @@
+package hub
+
+import (
+	"sync"
+	"time"
+
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped
+const subscriberBuffer = 64
+
+// Event is a mission event numbered for Server-Sent Events
+type Event struct {
+	ID uint64 `json:"id"`
+	models.MissionEvent
+}
+
+// Hub fans mission events out to subscribers and keeps the most recent ones
+// so reconnecting clients can resume after the last event they received.
+// Event IDs increase by one per event; they start from the startup time so
+// IDs handed out before a restart are recognised as too old to resume from.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	history     []Event
+	historySize int
+	subscribers map[*Subscription]struct{}
+}
+
+// New returns a hub remembering the last historySize events
+func New(historySize int) *Hub {
+	return &Hub{
+		lastID:      uint64(time.Now().UnixMicro()),
+		historySize: historySize,
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish numbers the event, remembers it and delivers it to every matching
+// subscriber. Subscribers too slow to keep up are dropped; they can resume
+// from the last event they received.
+func (h *Hub) Publish(event models.MissionEvent) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	e := Event{ID: h.lastID, MissionEvent: event}
+	h.history = append(h.history, e)
+	if len(h.history) > h.historySize {
+		h.history = h.history[len(h.history)-h.historySize:]
+	}
+	for sub := range h.subscribers {
+		if !sub.match(e) {
+			continue
+		}
+		select {
+		case sub.ch <- e:
+		default:
+			h.remove(sub)
+		}
+	}
+	return e
+}
+
+// Subscription receives the events matching its filter until it is closed
+type Subscription struct {
+	C      <-chan Event // Closed when the subscription is closed or dropped
+	LastID uint64       // ID of the last event published before subscribing
+	ch     chan Event
+	match  func(Event) bool
+	hub    *Hub
+}
+
+// Close stops the subscription; it is safe to call more than once
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// Subscribe registers a subscriber for the events accepted by match. With a
+// lastID > 0 it also returns the remembered matching events after lastID;
+// complete is false if some of them are no longer remembered (or lastID is
+// unknown), in which case the subscriber should reload the current state.
+func (h *Hub) Subscribe(match func(Event) bool, lastID uint64) (sub *Subscription, replay []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, LastID: h.lastID, ch: ch, match: match, hub: h}
+	h.subscribers[sub] = struct{}{}
+
+	if lastID == 0 {
+		return sub, nil, true
+	}
+	oldest := h.lastID + 1 - uint64(len(h.history))
+	if lastID+1 < oldest || lastID > h.lastID {
+		return sub, nil, false
+	}
+	for _, e := range h.history {
+		if e.ID > lastID && match(e) {
+			replay = append(replay, e)
+		}
+	}
+	return sub, replay, true
+}
+
+// remove unregisters and closes a subscription; h.mu must be held
+func (h *Hub) remove(sub *Subscription) {
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.ch)
+	}
+}
+
+// publishingStore publishes every mission event it records into the hub
+type publishingStore struct {
+	store.Store
+	hub *Hub
+}
+
+// Wrap returns a store that publishes each event into the hub once it was
+// recorded, so every status transition - reported by soldiers or made by the
+// commander itself - reaches stream subscribers
+func (h *Hub) Wrap(s store.Store) store.Store {
+	return &publishingStore{Store: s, hub: h}
+}
+
+// AddEvent records the event and publishes it into the hub
+func (s *publishingStore) AddEvent(event models.MissionEvent) error {
+	if err := s.Store.AddEvent(event); err != nil {
+		return err
+	}
+	s.hub.Publish(event)
+	return nil
+}
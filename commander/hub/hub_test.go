@@ -0,0 +1,79 @@
+package hub
+
+import (
+	"testing"
+
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+func forMission(id string) func(Event) bool {
+	return func(e Event) bool { return e.MissionID == id }
+}
+
+func TestHub_PublishAndResume(t *testing.T) {
+	h := New(3)
+
+	sub, replay, complete := h.Subscribe(forMission("m1"), 0)
+	defer sub.Close()
+	if replay != nil || !complete {
+		t.Fatalf("fresh subscriptions replay nothing")
+	}
+
+	first := h.Publish(models.MissionEvent{MissionID: "m1", Status: models.StatusQueued})
+	h.Publish(models.MissionEvent{MissionID: "m2", Status: models.StatusQueued})
+	h.Publish(models.MissionEvent{MissionID: "m1", Status: models.StatusInProgress})
+
+	if e := <-sub.C; e.ID != first.ID || e.Status != models.StatusQueued {
+		t.Fatalf("expected the first m1 event, got %+v", e)
+	}
+	if e := <-sub.C; e.Status != models.StatusInProgress {
+		t.Fatalf("expected only m1 events, got %+v", e)
+	}
+
+	// Resuming after the first event replays the rest of m1's events
+	resumed, replay, complete := h.Subscribe(forMission("m1"), first.ID)
+	defer resumed.Close()
+	if !complete || len(replay) != 1 || replay[0].Status != models.StatusInProgress {
+		t.Fatalf("expected IN_PROGRESS to be replayed, got %+v (complete %v)", replay, complete)
+	}
+
+	// Events pushed out of the history cannot be replayed
+	h.Publish(models.MissionEvent{MissionID: "m1", Status: models.StatusCompleted})
+	h.Publish(models.MissionEvent{MissionID: "m2", Status: models.StatusCompleted})
+	for _, lastID := range []uint64{first.ID, first.ID + 100} {
+		gap, _, complete := h.Subscribe(forMission("m1"), lastID)
+		gap.Close()
+		if complete {
+			t.Fatalf("last ID %d: expected an incomplete replay", lastID)
+		}
+	}
+}
+
+func TestHub_DropsSlowSubscriber(t *testing.T) {
+	h := New(10)
+	sub, _, _ := h.Subscribe(func(Event) bool { return true }, 0)
+
+	for range subscriberBuffer + 1 {
+		h.Publish(models.MissionEvent{MissionID: "m1"})
+	}
+	for range sub.C {
+	}
+	sub.Close() // Closing a dropped subscription is harmless
+}
+
+func TestHub_WrapPublishesRecordedEvents(t *testing.T) {
+	h := New(10)
+	missions := h.Wrap(store.NewMemoryStore())
+	sub, _, _ := h.Subscribe(forMission("m1"), 0)
+	defer sub.Close()
+
+	missions.AddEvent(models.MissionEvent{MissionID: "m1", Status: models.StatusQueued})
+
+	if e := <-sub.C; e.Status != models.StatusQueued {
+		t.Fatalf("expected the recorded event, got %+v", e)
+	}
+	if events, _ := missions.Events("m1"); len(events) != 1 {
+		t.Fatalf("expected the event to be stored, got %d", len(events))
+	}
+}
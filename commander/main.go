@@ -1,32 +1,147 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"mission_control/broker/amqpbroker"
+	"mission_control/commander/config"
 	"mission_control/commander/handlers"
+	"mission_control/commander/hub"
 	"mission_control/commander/middleware"
+	"mission_control/commander/orders"
 	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
+	"mission_control/commander/scheduler"
+	"mission_control/commander/store"
+	"mission_control/commander/sweeper"
+	"mission_control/commander/webhooks"
 )
 
 func main() {
-	// Connect to RabbitMQ
-	conn, ch := rabbitmq.SetupRabbitMQ()
-	defer conn.Close()
-	defer ch.Close()
+	// Shut down gracefully on SIGINT and SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Consumers and background jobs stop with ctx; shutdown waits for them
+	var background sync.WaitGroup
+
+	// Connect to RabbitMQ in the background. Publishes are confirmed, so missions count as
+	// queued only once the broker has them; the topology is declared again after every reconnect.
+	messages := amqpbroker.NewBroker(config.GetRabbitMQURL(), config.GetPublishConfirmTimeout())
+	defer messages.Close()
+	if err := messages.Declare(rabbitmq.Topology()); err != nil {
+		log.Fatalf("Failed to declare RabbitMQ topology: %v", err)
+	}
+
+	// Open the mission store selected by config
+	missions, err := store.New()
+	if err != nil {
+		log.Fatalf("Failed to open mission store: %v", err)
+	}
+	defer missions.Close()
+	background.Go(func() { store.PurgeExpiredKeysEvery(ctx, missions, time.Hour) })
+
+	// Publish every recorded mission event to stream subscribers
+	events := hub.New(config.GetStreamHistorySize())
+	missions = events.Wrap(missions)
+
+	// Order types accepted by POST /missions
+	orderTypes := orders.NewDefaultRegistry()
+	if path := config.GetOrderTypesFile(); path != "" {
+		if err := orderTypes.LoadFile(path); err != nil {
+			log.Fatalf("Failed to load order types: %v", err)
+		}
+	}
+
+	// Track soldiers from their registrations and heartbeats
+	soldiers := registry.New(config.GetSoldierStaleAfter(), config.GetSoldierOfflineAfter())
+	background.Go(func() { rabbitmq.ConsumeHeartbeats(ctx, messages, soldiers) })
+
+	// Publish scheduled missions when they are due
+	background.Go(func() { scheduler.New(messages, missions, soldiers, config.GetSchedulerInterval()).Run(ctx) })
+
+	// Time out missions whose soldier stopped reporting
+	background.Go(func() {
+		sweeper.New(messages, missions, soldiers, config.GetSweeperInterval(), config.GetTimeoutRepublishLimit()).Run(ctx)
+	})
+
+	// Notify webhook subscribers and mission callbacks of status transitions
+	background.Go(func() {
+		webhooks.New(missions, events, config.GetWebhookURLs(), config.GetWebhookSecret(), config.GetWebhookMaxAttempts()).Run(ctx)
+	})
 
 	// Start status consumer
-	go rabbitmq.ConsumeStatusUpdates(ch)
+	background.Go(func() { rabbitmq.ConsumeStatusUpdates(ctx, messages, missions, config.GetMaxResultBytes()) })
+
+	// Keep dead-lettered messages for inspection and replay
+	background.Go(func() { rabbitmq.ConsumeDeadLetters(ctx, messages, missions) })
 
 	// Public login endpoint
 	http.HandleFunc("/login", handlers.LoginHandler)
 	http.HandleFunc("/refresh", handlers.RefreshHandler)
 	http.HandleFunc("/health", handlers.HealthCheckHandler)
-	
+
 	// Protected endpoints
-	http.Handle("/missions", middleware.JWTMiddleware(http.HandlerFunc(handlers.CreateMissionHandler(ch))))
-	http.Handle("/missions/", middleware.JWTMiddleware(http.HandlerFunc(handlers.GetMissionHandler)))
+	http.Handle("POST /missions", middleware.JWTMiddleware(handlers.CreateMissionHandler(messages, missions, missions, orderTypes, soldiers)))
+	http.Handle("GET /missions", middleware.JWTMiddleware(handlers.ListMissionsHandler(missions)))
+	http.Handle("GET /missions/stream", middleware.JWTMiddleware(handlers.StreamMissionsHandler(events)))
+	http.Handle("GET /missions/{id}", middleware.JWTMiddleware(handlers.GetMissionHandler(missions)))
+	http.Handle("GET /missions/{id}/events", middleware.JWTMiddleware(handlers.GetMissionEventsHandler(missions)))
+	http.Handle("GET /missions/{id}/stream", middleware.JWTMiddleware(handlers.StreamMissionHandler(missions, events)))
+	http.Handle("GET /missions/{id}/webhooks", middleware.JWTMiddleware(handlers.GetMissionWebhooksHandler(missions, missions)))
+	http.Handle("POST /missions/{id}/cancel", middleware.JWTMiddleware(handlers.CancelMissionHandler(messages, missions)))
+	http.Handle("GET /order-types", middleware.JWTMiddleware(handlers.ListOrderTypesHandler(orderTypes)))
+	http.Handle("GET /soldiers", middleware.JWTMiddleware(handlers.ListSoldiersHandler(soldiers)))
+	http.Handle("GET /soldiers/{id}", middleware.JWTMiddleware(handlers.GetSoldierHandler(soldiers)))
+	http.Handle("GET /admin/dead-letters", middleware.JWTMiddleware(handlers.ListDeadLettersHandler(missions)))
+	http.Handle("DELETE /admin/dead-letters", middleware.JWTMiddleware(handlers.PurgeDeadLettersHandler(missions)))
+	http.Handle("GET /admin/dead-letters/{id}", middleware.JWTMiddleware(handlers.GetDeadLetterHandler(missions)))
+	http.Handle("DELETE /admin/dead-letters/{id}", middleware.JWTMiddleware(handlers.DeleteDeadLetterHandler(missions)))
+	http.Handle("POST /admin/dead-letters/{id}/replay", middleware.JWTMiddleware(handlers.ReplayDeadLetterHandler(messages, missions)))
+
+	// Request contexts end as soon as shutdown begins: streams close and publishes
+	// stop retrying, while publishes already in flight are left to finish
+	requests, endStreams := context.WithCancel(context.Background())
+	server := &http.Server{Addr: ":8080", BaseContext: func(net.Listener) context.Context { return requests }}
+	server.RegisterOnShutdown(endStreams)
+
+	go func() {
+		log.Println("Commander API listening on :8080")
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("HTTP server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	drainTimeout := config.GetDrainTimeout()
+	log.Printf("Shutting down: draining requests, consumers and background jobs (up to %v)...", drainTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+
+	// Stop accepting requests and let the ones in flight finish
+	if err := server.Shutdown(drainCtx); err != nil {
+		log.Printf("HTTP requests still running after the drain timeout: %v", err)
+	}
 
-	log.Println("Commander API listening on :8080")
-	log.Fatal(http.ListenAndServe(":8080", nil))
+	// Unacknowledged deliveries are requeued when the connection closes
+	drained := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-drainCtx.Done():
+		log.Println("Consumers and background jobs still running after the drain timeout")
+	}
+	log.Println("Commander stopped")
 }
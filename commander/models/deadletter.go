@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// DeadLetter is a message that was dead-lettered because a consumer could not
+// process it, kept by the commander until it is replayed or purged
+type DeadLetter struct {
+	ID             uint64         `json:"id"`
+	Queue          string         `json:"queue"`       // Queue the message was rejected from
+	Exchange       string         `json:"exchange"`    // Exchange the message was published to; empty is the default exchange
+	RoutingKey     string         `json:"routing_key"` // Routing key the message was published with
+	Reason         string         `json:"reason"`      // Why it failed, or rejected, expired, maxlen or delivery_limit from RabbitMQ
+	Count          int64          `json:"count"`       // How many times it was dead-lettered from Queue
+	DeadLetteredAt time.Time      `json:"dead_lettered_at"`
+	ContentType    string         `json:"content_type,omitempty"`
+	Priority       uint8          `json:"priority,omitempty"`
+	Headers        map[string]any `json:"headers,omitempty"` // Message headers, including RabbitMQ's x-death
+	Body           string         `json:"body"`
+}
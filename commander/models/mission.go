@@ -1,10 +1,63 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
 // Mission represents a command sent to the soldier service
 type Mission struct {
-	MissionID     string `json:"mission_id"`
-	Order  string `json:"order"`
-	Status string `json:"status"`
+	MissionID string    `json:"mission_id"`
+	Order     string    `json:"order"`
+	Status    string    `json:"status"`
+	Priority  uint8     `json:"priority"`      // 0 (lowest) to MaxPriority (most urgent)
+	Attempt   int       `json:"attempt"`       // Starts at 1, incremented when the mission is republished
+	Seq       int64     `json:"seq,omitempty"` // Last applied soldier sequence number of this attempt
+	CreatedAt time.Time `json:"created_at"`
+	// Target routes the mission to one soldier ("soldier:<id>") or unit
+	// ("unit:<name>"); empty or "any" lets any soldier take it
+	Target string `json:"target,omitempty"`
+	// Capabilities a soldier needs to run the mission; such missions are
+	// routed to a capable soldier's own queue
+	Capabilities []string `json:"capabilities,omitempty"`
+	// Soldier is the soldier whose own queue the current attempt was published
+	// to at RoutedAt; the sweeper republishes QUEUED missions whose soldier
+	// went away, as its queue is deleted with the order in it
+	Soldier  string     `json:"soldier,omitempty"`
+	RoutedAt *time.Time `json:"routed_at,omitempty"`
+	// CallbackURL receives a webhook on every status transition of the mission
+	CallbackURL string `json:"callback_url,omitempty"`
+	// Type names the registered order type; Params were validated against its schema
+	Type   string          `json:"type,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	// TimeoutSeconds bounds how long an attempt may stay IN_PROGRESS
+	TimeoutSeconds int64 `json:"timeout_seconds,omitempty"`
+	// Deadline is when the sweeper marks the IN_PROGRESS attempt TIMED_OUT
+	Deadline *time.Time `json:"deadline,omitempty"`
+	// ExecuteAt is when a SCHEDULED mission will be published to soldiers
+	ExecuteAt *time.Time `json:"execute_at,omitempty"`
+	// MaxAttempts is how many attempts the mission gets when the soldier
+	// reports it FAILED; zero or one means no retries. Backoff spaces them.
+	MaxAttempts int      `json:"max_attempts,omitempty"`
+	Backoff     *Backoff `json:"backoff,omitempty"`
+	// RetryAt is when a RETRY_PENDING mission is republished as its next attempt
+	RetryAt *time.Time `json:"retry_at,omitempty"`
+	// Attempts holds the outcome of every finished attempt, oldest first
+	Attempts []AttemptOutcome `json:"attempts,omitempty"`
+	// CancelRequested is set when an in-flight mission was asked to stop;
+	// the soldier confirms by reporting CANCELLED
+	CancelRequested bool `json:"cancel_requested,omitempty"`
+	// Outcome of the current attempt as reported by the soldier. Result is
+	// replaced by a TruncatedResult when it exceeds MAX_RESULT_BYTES.
+	Result          json.RawMessage `json:"result,omitempty"`
+	ResultTruncated bool            `json:"result_truncated,omitempty"`
+	Error           *MissionError   `json:"error,omitempty"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"`
+	// Progress is the latest progress the soldier reported for the current attempt
+	Progress *Progress `json:"progress,omitempty"`
 }
 
 // GetMission is used for responses where JWT should not be included
@@ -14,3 +67,72 @@ type GetMission struct {
 	Status string `json:"status"`
 	JWT    string `json:"-"`
 }
+
+// Mission targets
+const (
+	TargetAny           = "any"
+	TargetSoldierPrefix = "soldier:"
+	TargetUnitPrefix    = "unit:"
+)
+
+// ErrInvalidTarget is returned for targets that are not "any", "soldier:<id>" or "unit:<name>"
+var ErrInvalidTarget = errors.New(`target must be "any", "soldier:<id>" or "unit:<name>"`)
+
+// ParseTarget splits a target into its kind prefix and name. Names may only
+// hold letters, digits, "-" and "_" so they are safe in topic routing keys.
+// Untargeted missions return empty strings.
+func ParseTarget(target string) (prefix, name string, err error) {
+	if target == "" || target == TargetAny {
+		return "", "", nil
+	}
+	for _, prefix := range []string{TargetSoldierPrefix, TargetUnitPrefix} {
+		if name, ok := strings.CutPrefix(target, prefix); ok && validTargetName(name) {
+			return prefix, name, nil
+		}
+	}
+	return "", "", ErrInvalidTarget
+}
+
+func validTargetName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxPriority is the highest mission priority; orders_queue is declared with it as x-max-priority
+const MaxPriority = 9
+
+const (
+	SourceCommander = "commander" // Status set by the commander itself
+	SourceSoldier   = "soldier"   // Status reported by a soldier over status_queue
+)
+
+// MissionEvent records a single status transition of a mission
+type MissionEvent struct {
+	MissionID string    `json:"mission_id"`
+	Status    string    `json:"status"`
+	Source    string    `json:"source"`
+	Attempt   int       `json:"attempt,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+	// Late marks updates that arrived after their attempt timed out or was
+	// superseded; they are kept for debugging but did not change the mission
+	Late bool `json:"late,omitempty"`
+	// Mission is the mission right after the transition, handed to webhooks;
+	// it is not stored with the event
+	Mission *Mission `json:"-"`
+}
+
+// ControlCancel asks soldiers to skip or abort a mission
+const ControlCancel = "cancel"
+
+// ControlMessage is broadcast to soldiers over the control exchange
+type ControlMessage struct {
+	Type      string `json:"type"`
+	MissionID string `json:"mission_id"`
+}
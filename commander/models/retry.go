@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Backoff strategies of a retry policy
+const (
+	BackoffFixed       = "fixed"
+	BackoffExponential = "exponential"
+)
+
+// MaxAttemptsLimit caps the max_attempts a mission may ask for
+const MaxAttemptsLimit = 10
+
+// Backoff decides how long a failed mission waits before its next attempt
+type Backoff struct {
+	Type           string `json:"type"`                  // BackoffFixed or BackoffExponential
+	InitialSeconds int64  `json:"initial_seconds"`       // Wait after the first failed attempt
+	MaxSeconds     int64  `json:"max_seconds,omitempty"` // Upper bound of exponential waits
+}
+
+// DefaultBackoff is used by missions with retries that do not set a backoff
+var DefaultBackoff = Backoff{Type: BackoffExponential, InitialSeconds: 5, MaxSeconds: 300}
+
+// Delay returns how long to wait after the given failed attempt. Exponential
+// backoff doubles the initial wait with every attempt, up to MaxSeconds.
+func (b Backoff) Delay(attempt int) time.Duration {
+	delay := time.Duration(b.InitialSeconds) * time.Second
+	limit := time.Duration(b.MaxSeconds) * time.Second
+	if b.Type == BackoffExponential {
+		for i := 1; i < attempt && (limit == 0 || delay < limit); i++ {
+			delay *= 2
+		}
+	}
+	if limit > 0 && delay > limit {
+		delay = limit
+	}
+	return delay
+}
+
+// AttemptOutcome records how one attempt of a mission ended
+type AttemptOutcome struct {
+	Attempt    int           `json:"attempt"`
+	Status     string        `json:"status"`
+	Error      *MissionError `json:"error,omitempty"`
+	StartedAt  *time.Time    `json:"started_at,omitempty"`
+	FinishedAt *time.Time    `json:"finished_at,omitempty"`
+}
+
+// CanRetryFailure reports whether a failed attempt leaves the mission another one
+func (m *Mission) CanRetryFailure() bool {
+	return m.Attempt < m.MaxAttempts
+}
+
+// RetryDelay returns how long the mission waits before retrying its current attempt
+func (m *Mission) RetryDelay() time.Duration {
+	backoff := DefaultBackoff
+	if m.Backoff != nil {
+		backoff = *m.Backoff
+	}
+	return backoff.Delay(m.Attempt)
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	exponential := Backoff{Type: BackoffExponential, InitialSeconds: 5, MaxSeconds: 30}
+	for attempt, expected := range map[int]time.Duration{1: 5 * time.Second, 2: 10 * time.Second, 3: 20 * time.Second, 4: 30 * time.Second, 10: 30 * time.Second} {
+		if got := exponential.Delay(attempt); got != expected {
+			t.Fatalf("exponential attempt %d: expected %v, got %v", attempt, expected, got)
+		}
+	}
+
+	fixed := Backoff{Type: BackoffFixed, InitialSeconds: 7}
+	if got := fixed.Delay(5); got != 7*time.Second {
+		t.Fatalf("fixed: expected 7s, got %v", got)
+	}
+
+	// Missions without a backoff use the default one
+	if got := (&Mission{Attempt: 1, MaxAttempts: 3}).RetryDelay(); got != DefaultBackoff.Delay(1) {
+		t.Fatalf("expected default backoff, got %v", got)
+	}
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Heartbeat message types soldiers publish to heartbeat_queue
+const (
+	HeartbeatRegister   = "register"   // Sent once on startup
+	HeartbeatBeat       = "heartbeat"  // Sent periodically while the soldier runs
+	HeartbeatDeregister = "deregister" // Sent when the soldier shuts down cleanly
+)
+
+// Heartbeat is published by soldiers to announce themselves and report
+// which missions they are running
+type Heartbeat struct {
+	Type      string `json:"type"`
+	SoldierID string `json:"soldier_id"`
+	Version   string `json:"version,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	// Capabilities are the order capabilities the soldier can serve
+	Capabilities []string `json:"capabilities,omitempty"`
+	Missions     []string `json:"missions"`
+	// Workers is the size of the soldier's worker pool, BusyWorkers how many are running missions
+	Workers     int       `json:"workers,omitempty"`
+	BusyWorkers int       `json:"busy_workers"`
+	SentAt      time.Time `json:"sent_at"`
+}
+
+// HasFreeWorker reports whether the soldier had an idle worker at its last
+// heartbeat; soldiers that do not report their workers are assumed to have one
+func (s *Soldier) HasFreeWorker() bool {
+	return s.Workers == 0 || s.BusyWorkers < s.Workers
+}
+
+// HasCapabilities reports whether the soldier can serve every required capability
+func (s *Soldier) HasCapabilities(required []string) bool {
+	for _, capability := range required {
+		if !slices.Contains(s.Capabilities, capability) {
+			return false
+		}
+	}
+	return true
+}
+
+// Soldier liveness states, derived from the time since the last heartbeat
+const (
+	SoldierOnline  = "ONLINE"
+	SoldierStale   = "STALE"
+	SoldierOffline = "OFFLINE"
+)
+
+// Soldier is a registered soldier as known to the commander
+type Soldier struct {
+	SoldierID    string    `json:"soldier_id"`
+	State        string    `json:"state"`
+	Version      string    `json:"version,omitempty"`
+	Hostname     string    `json:"hostname,omitempty"`
+	Unit         string    `json:"unit,omitempty"`
+	Capabilities []string  `json:"capabilities"`
+	Missions     []string  `json:"missions"`          // Missions the soldier reported running in its last heartbeat
+	Workers      int       `json:"workers,omitempty"` // Size of the soldier's worker pool
+	BusyWorkers  int       `json:"busy_workers"`      // Workers running missions at the last heartbeat
+	RegisteredAt time.Time `json:"registered_at"`
+	LastSeen     time.Time `json:"last_seen"`
+	// Deregistered is set when the soldier announced a clean shutdown
+	Deregistered bool `json:"-"`
+}
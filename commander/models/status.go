@@ -0,0 +1,144 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"time"
+	"unicode/utf8"
+)
+
+// Mission lifecycle states
+const (
+	StatusScheduled  = "SCHEDULED"
+	StatusQueued     = "QUEUED"
+	StatusInProgress = "IN_PROGRESS"
+	StatusCompleted  = "COMPLETED"
+	StatusFailed     = "FAILED"
+	StatusCancelled  = "CANCELLED"
+	StatusTimedOut   = "TIMED_OUT"
+	// StatusRetryPending holds a mission whose attempt failed until its
+	// backoff has passed and it is republished as the next attempt
+	StatusRetryPending = "RETRY_PENDING"
+)
+
+var (
+	// ErrInvalidTransition is returned when a status change is not allowed by the lifecycle
+	ErrInvalidTransition = errors.New("invalid status transition")
+	// ErrStaleUpdate is returned when a status update is older than the last applied one
+	ErrStaleUpdate = errors.New("stale status update")
+)
+
+// transitions lists the statuses each status may move to within an attempt.
+// Terminal statuses have no outgoing transitions.
+var transitions = map[string][]string{
+	"":                 {StatusQueued, StatusScheduled},
+	StatusScheduled:    {StatusQueued, StatusFailed, StatusCancelled},
+	StatusQueued:       {StatusInProgress, StatusCompleted, StatusFailed, StatusCancelled, StatusTimedOut, StatusRetryPending},
+	StatusInProgress:   {StatusCompleted, StatusFailed, StatusCancelled, StatusTimedOut, StatusRetryPending},
+	StatusRetryPending: {StatusFailed, StatusCancelled},
+}
+
+// requeues lists the statuses a mission leaves for QUEUED when it is
+// requeued as a new attempt. Status updates never start an attempt, so these
+// moves are not in transitions; only CanRetry allows them.
+var requeues = []string{StatusRetryPending, StatusTimedOut}
+
+// CanTransition reports whether a mission may move from one status to another within an attempt
+func CanTransition(from, to string) bool {
+	return slices.Contains(transitions[from], to)
+}
+
+// CanRetry reports whether a mission may be requeued as a new attempt
+func CanRetry(status string) bool {
+	return slices.Contains(requeues, status)
+}
+
+// IsTerminal reports whether no further transitions are allowed from status.
+// A TIMED_OUT mission may still be requeued as a new attempt (see CanRetry).
+func IsTerminal(status string) bool {
+	switch status {
+	case StatusCompleted, StatusFailed, StatusCancelled, StatusTimedOut:
+		return true
+	}
+	return false
+}
+
+// StatusUpdate is the status message soldiers publish to status_queue.
+// Seq increases with every update a soldier sends for a mission attempt, so
+// late redeliveries can be told apart from fresh updates. Attempt echoes the
+// attempt the soldier received. Zero means unknown for both.
+// Final updates carry the mission result or the reason it failed.
+type StatusUpdate struct {
+	MissionID       string          `json:"mission_id"`
+	Status          string          `json:"status"`
+	Seq             int64           `json:"seq,omitempty"`
+	Attempt         int             `json:"attempt,omitempty"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	ResultTruncated bool            `json:"result_truncated,omitempty"`
+	Error           *MissionError   `json:"error,omitempty"`
+	StartedAt       *time.Time      `json:"started_at,omitempty"`  // When the soldier started the attempt
+	FinishedAt      *time.Time      `json:"finished_at,omitempty"` // When the soldier finished the attempt
+	Progress        *Progress       `json:"progress,omitempty"`    // Set on StatusProgress updates
+}
+
+// StatusProgress marks soldier updates that only report how far a running
+// mission has got. They are stored on the mission but are not a lifecycle
+// state and never change its status.
+const StatusProgress = "PROGRESS"
+
+// Progress is the latest progress reported for a running mission
+type Progress struct {
+	Percent   int       `json:"percent"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// MissionError explains why a mission attempt did not complete
+type MissionError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// ErrorCodePublishFailed is the error code of missions the commander could not publish
+const ErrorCodePublishFailed = "PUBLISH_FAILED"
+
+// MaxErrorMessageLength caps the error message kept for a mission
+const MaxErrorMessageLength = 1024
+
+// TruncatedSuffix marks error messages that were cut to MaxErrorMessageLength
+const TruncatedSuffix = "...[truncated]"
+
+// TruncatedResult replaces results larger than the configured limit. Preview
+// holds the start of the original result, which is usually not valid JSON.
+type TruncatedResult struct {
+	Truncated    bool   `json:"truncated"`
+	OriginalSize int    `json:"original_size"`
+	Preview      string `json:"preview"`
+}
+
+// LimitSize keeps the update within maxResultBytes: larger results are
+// replaced by a TruncatedResult and long error messages are cut, both
+// flagged so clients know they are not seeing the full payload.
+func (u *StatusUpdate) LimitSize(maxResultBytes int) {
+	if len(u.Result) > maxResultBytes {
+		u.Result, _ = json.Marshal(TruncatedResult{
+			Truncated:    true,
+			OriginalSize: len(u.Result),
+			Preview:      cut(string(u.Result), maxResultBytes),
+		})
+		u.ResultTruncated = true
+	}
+	if u.Error != nil && len(u.Error.Message) > MaxErrorMessageLength {
+		message := cut(u.Error.Message, MaxErrorMessageLength) + TruncatedSuffix
+		u.Error = &MissionError{Code: u.Error.Code, Message: message}
+	}
+}
+
+// cut shortens s to at most n bytes without splitting a multi-byte character
+func cut(s string, n int) string {
+	for n > 0 && n < len(s) && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
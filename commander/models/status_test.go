@@ -0,0 +1,50 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStatusUpdate_LimitSize(t *testing.T) {
+	small := StatusUpdate{Result: json.RawMessage(`{"found":3}`)}
+	small.LimitSize(64)
+	if string(small.Result) != `{"found":3}` || small.ResultTruncated {
+		t.Fatalf("small results must be kept, got %s", small.Result)
+	}
+
+	large := StatusUpdate{
+		Result: json.RawMessage(`"` + strings.Repeat("é", 100) + `"`),
+		Error:  &MissionError{Code: "E", Message: strings.Repeat("x", MaxErrorMessageLength+1)},
+	}
+	large.LimitSize(64)
+	if !large.ResultTruncated {
+		t.Fatalf("expected result to be flagged as truncated")
+	}
+	var marker TruncatedResult
+	if err := json.Unmarshal(large.Result, &marker); err != nil {
+		t.Fatalf("truncated result must stay valid JSON: %v", err)
+	}
+	if !marker.Truncated || marker.OriginalSize != 202 || len(marker.Preview) > 64 {
+		t.Fatalf("unexpected truncation marker: %+v", marker)
+	}
+	if !strings.HasSuffix(large.Error.Message, TruncatedSuffix) || len(large.Error.Message) != MaxErrorMessageLength+len(TruncatedSuffix) {
+		t.Fatalf("expected error message cut with suffix, got %d bytes", len(large.Error.Message))
+	}
+}
+
+func TestLifecycle_RequeueIsNotATransition(t *testing.T) {
+	for _, status := range []string{StatusRetryPending, StatusTimedOut} {
+		if !CanRetry(status) {
+			t.Fatalf("expected %s missions to be requeueable", status)
+		}
+		if CanTransition(status, StatusQueued) {
+			t.Fatalf("expected no status update to move %s back to QUEUED", status)
+		}
+	}
+	for _, status := range []string{StatusQueued, StatusInProgress, StatusCompleted, StatusFailed, StatusCancelled} {
+		if CanRetry(status) {
+			t.Fatalf("expected %s missions not to be requeueable", status)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// WebhookEventType is the type of the event delivered on mission status transitions
+const WebhookEventType = "mission.status_changed"
+
+// WebhookEvent is the JSON body POSTed to webhook receivers
+type WebhookEvent struct {
+	ID      uint64       `json:"id"` // Same as the mission stream event ID
+	Type    string       `json:"type"`
+	Event   MissionEvent `json:"event"`
+	Mission *Mission     `json:"mission"` // The mission as stored right after the transition
+}
+
+// WebhookDelivery records one attempt to deliver a webhook event
+type WebhookDelivery struct {
+	MissionID  string    `json:"mission_id"`
+	EventID    uint64    `json:"event_id"`
+	URL        string    `json:"url"`
+	Status     string    `json:"status"` // Mission status reported by the event
+	Attempt    int       `json:"attempt"`
+	StatusCode int       `json:"status_code,omitempty"` // HTTP status answered by the receiver
+	Error      string    `json:"error,omitempty"`
+	Delivered  bool      `json:"delivered"`
+	Timestamp  time.Time `json:"timestamp"`
+	DurationMs int64     `json:"duration_ms"`
+}
@@ -0,0 +1,63 @@
+package orders
+
+import "encoding/json"
+
+// builtinTypes are registered by NewDefaultRegistry; ORDER_TYPES_FILE can add or override types
+var builtinTypes = []OrderType{
+	{
+		Name:        "recon",
+		Description: "Scout an area and report what was found",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"required": ["target"],
+			"additionalProperties": false,
+			"properties": {
+				"target": {"type": "string", "minLength": 1},
+				"radius_km": {"type": "number", "exclusiveMinimum": 0}
+			}
+		}`),
+		DefaultTimeout:  "10m",
+		DefaultPriority: 3,
+	},
+	{
+		Name:        "patrol",
+		Description: "Walk a route of waypoints, optionally several times",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"required": ["route"],
+			"additionalProperties": false,
+			"properties": {
+				"route": {"type": "array", "minItems": 1, "items": {"type": "string", "minLength": 1}},
+				"loops": {"type": "integer", "minimum": 1, "maximum": 10}
+			}
+		}`),
+		DefaultTimeout:  "30m",
+		DefaultPriority: 1,
+	},
+	{
+		Name:        "strike",
+		Description: "Engage a target",
+		Schema: json.RawMessage(`{
+			"type": "object",
+			"required": ["target"],
+			"additionalProperties": false,
+			"properties": {
+				"target": {"type": "string", "minLength": 1},
+				"munitions": {"type": "integer", "minimum": 1}
+			}
+		}`),
+		DefaultTimeout:  "5m",
+		DefaultPriority: 8,
+	},
+}
+
+// NewDefaultRegistry returns a registry holding the built-in order types
+func NewDefaultRegistry() *Registry {
+	r := NewRegistry()
+	for _, t := range builtinTypes {
+		if err := r.Register(t); err != nil {
+			panic(err) // built-in schemas are fixed at compile time
+		}
+	}
+	return r
+}
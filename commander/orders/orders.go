@@ -0,0 +1,202 @@
+package orders
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mission_control/commander/models"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+)
+
+// ErrUnknownType is returned for order types that are not registered
+var ErrUnknownType = errors.New("unknown order type")
+
+// OrderType declares an order soldiers can execute: the JSON Schema its
+// params must satisfy and the defaults applied to missions of this type
+type OrderType struct {
+	Name            string          `json:"name"`
+	Description     string          `json:"description,omitempty"`
+	Schema          json.RawMessage `json:"schema"`
+	DefaultTimeout  string          `json:"default_timeout,omitempty"` // e.g. "5m"; empty uses the server default
+	DefaultPriority int             `json:"default_priority"`
+	// Capabilities a soldier needs to run orders of this type
+	Capabilities []string `json:"capabilities,omitempty"`
+
+	schema  *jsonschema.Schema
+	timeout time.Duration
+}
+
+// Timeout returns the default timeout of the type, zero if it has none
+func (t *OrderType) Timeout() time.Duration {
+	return t.timeout
+}
+
+// FieldError describes why a single params field is invalid
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validate checks params against the type's schema and returns one error per
+// invalid field; a nil slice means params are valid
+func (t *OrderType) Validate(params json.RawMessage) []FieldError {
+	if len(params) == 0 {
+		params = json.RawMessage("{}")
+	}
+	inst, err := jsonschema.UnmarshalJSON(bytes.NewReader(params))
+	if err != nil {
+		return []FieldError{{Field: "params", Message: "must be valid JSON"}}
+	}
+	err = t.schema.Validate(inst)
+	if err == nil {
+		return nil
+	}
+	var ve *jsonschema.ValidationError
+	if !errors.As(err, &ve) {
+		return []FieldError{{Field: "params", Message: err.Error()}}
+	}
+
+	var fieldErrors []FieldError
+	for _, unit := range ve.BasicOutput().Errors {
+		if unit.Error == nil {
+			continue
+		}
+		switch k := unit.Error.Kind.(type) {
+		case *kind.Required:
+			// Report the missing property itself rather than its parent object
+			for _, name := range k.Missing {
+				fieldErrors = append(fieldErrors, FieldError{Field: fieldName(unit.InstanceLocation + "/" + name), Message: "is required"})
+			}
+		case *kind.AdditionalProperties:
+			for _, name := range k.Properties {
+				fieldErrors = append(fieldErrors, FieldError{Field: fieldName(unit.InstanceLocation + "/" + name), Message: "is not allowed"})
+			}
+		default:
+			fieldErrors = append(fieldErrors, FieldError{Field: fieldName(unit.InstanceLocation), Message: unit.Error.String()})
+		}
+	}
+	return fieldErrors
+}
+
+// fieldName turns a JSON pointer into a dotted path below "params"
+func fieldName(pointer string) string {
+	if pointer == "" || pointer == "/" {
+		return "params"
+	}
+	return "params" + strings.ReplaceAll(pointer, "/", ".")
+}
+
+// normalizeCapabilities trims and lowercases capabilities and returns them
+// sorted, without empty names and duplicates
+func normalizeCapabilities(capabilities []string) []string {
+	var normalized []string
+	for _, capability := range capabilities {
+		if capability = strings.ToLower(strings.TrimSpace(capability)); capability != "" {
+			normalized = append(normalized, capability)
+		}
+	}
+	slices.Sort(normalized)
+	return slices.Compact(normalized)
+}
+
+// Registry holds the order types the commander accepts
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]*OrderType
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{types: make(map[string]*OrderType)}
+}
+
+// Register compiles the type's schema and adds it, replacing any type with the same name.
+// Capabilities are trimmed and lowercased like those of soldiers and missions.
+func (r *Registry) Register(t OrderType) error {
+	if t.Name == "" {
+		return errors.New("order type needs a name")
+	}
+	if t.DefaultPriority < 0 || t.DefaultPriority > models.MaxPriority {
+		return fmt.Errorf("order type %s: default_priority must be between 0 and %d", t.Name, models.MaxPriority)
+	}
+	if t.DefaultTimeout != "" {
+		timeout, err := time.ParseDuration(t.DefaultTimeout)
+		if err != nil || timeout < time.Second {
+			return fmt.Errorf("order type %s: invalid default_timeout %q", t.Name, t.DefaultTimeout)
+		}
+		t.timeout = timeout
+	}
+	t.Capabilities = normalizeCapabilities(t.Capabilities)
+	if len(t.Schema) == 0 {
+		t.Schema = json.RawMessage(`{"type":"object"}`)
+	}
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(t.Schema))
+	if err != nil {
+		return fmt.Errorf("order type %s: schema is not valid JSON: %w", t.Name, err)
+	}
+	url := "orders/" + t.Name + ".json"
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(url, doc); err != nil {
+		return fmt.Errorf("order type %s: %w", t.Name, err)
+	}
+	if t.schema, err = c.Compile(url); err != nil {
+		return fmt.Errorf("order type %s: invalid schema: %w", t.Name, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[t.Name] = &t
+	return nil
+}
+
+// Get returns the named order type or ErrUnknownType
+func (r *Registry) Get(name string) (*OrderType, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.types[name]
+	if !ok {
+		return nil, ErrUnknownType
+	}
+	return t, nil
+}
+
+// List returns all registered types sorted by name
+func (r *Registry) List() []*OrderType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]*OrderType, 0, len(r.types))
+	for _, t := range r.types {
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(a, b *OrderType) int { return strings.Compare(a.Name, b.Name) })
+	return types
+}
+
+// LoadFile registers every order type in a JSON file holding an array of types
+func (r *Registry) LoadFile(path string) error {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var types []OrderType
+	if err := json.Unmarshal(body, &types); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, t := range types {
+		if err := r.Register(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package orders
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestValidate_FieldErrors(t *testing.T) {
+	recon, err := NewDefaultRegistry().Get("recon")
+	if err != nil {
+		t.Fatalf("expected built-in recon type: %v", err)
+	}
+
+	if errs := recon.Validate(json.RawMessage(`{"target":"hill 42","radius_km":3}`)); errs != nil {
+		t.Fatalf("expected valid params, got %+v", errs)
+	}
+
+	errs := recon.Validate(json.RawMessage(`{"radius_km":-1,"colour":"red"}`))
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"params.target", "params.radius_km", "params.colour"} {
+		if !fields[field] {
+			t.Fatalf("expected an error on %s, got %+v", field, errs)
+		}
+	}
+
+	if errs := recon.Validate(json.RawMessage(`[1,2]`)); len(errs) != 1 || errs[0].Field != "params" {
+		t.Fatalf("expected a single error on params, got %+v", errs)
+	}
+}
+
+func TestRegistry_RegisterAndLoadFile(t *testing.T) {
+	r := NewRegistry()
+	if _, err := r.Get("recon"); err != ErrUnknownType {
+		t.Fatalf("expected ErrUnknownType, got %v", err)
+	}
+	if err := r.Register(OrderType{Name: "broken", Schema: json.RawMessage(`{"type":"nope"}`)}); err == nil {
+		t.Fatalf("expected invalid schema to be rejected")
+	}
+	if err := r.Register(OrderType{Name: "slow", DefaultTimeout: "soon"}); err == nil {
+		t.Fatalf("expected invalid default_timeout to be rejected")
+	}
+
+	path := filepath.Join(t.TempDir(), "order_types.json")
+	file := `[{"name":"resupply","schema":{"type":"object","required":["crates"]},"default_timeout":"2m","default_priority":5}]`
+	os.WriteFile(path, []byte(file), 0o600)
+	if err := r.LoadFile(path); err != nil {
+		t.Fatalf("failed to load order types: %v", err)
+	}
+
+	resupply, err := r.Get("resupply")
+	if err != nil {
+		t.Fatalf("expected loaded type: %v", err)
+	}
+	if resupply.Timeout() != 2*time.Minute || resupply.DefaultPriority != 5 {
+		t.Fatalf("unexpected defaults: %v %d", resupply.Timeout(), resupply.DefaultPriority)
+	}
+	if errs := resupply.Validate(nil); len(errs) != 1 || errs[0].Field != "params.crates" {
+		t.Fatalf("expected missing crates, got %+v", errs)
+	}
+	if list := r.List(); len(list) != 1 || list[0].Name != "resupply" {
+		t.Fatalf("unexpected list: %+v", list)
+	}
+}
+
+func TestRegistry_NormalizesCapabilities(t *testing.T) {
+	r := NewRegistry()
+	path := filepath.Join(t.TempDir(), "order_types.json")
+	file := `[{"name":"strike","capabilities":[" Recon","STRIKE","recon",""]}]`
+	os.WriteFile(path, []byte(file), 0o600)
+	if err := r.LoadFile(path); err != nil {
+		t.Fatalf("failed to load order types: %v", err)
+	}
+
+	strike, _ := r.Get("strike")
+	if !slices.Equal(strike.Capabilities, []string{"recon", "strike"}) {
+		t.Fatalf("expected trimmed, lowercased and deduplicated capabilities, got %q", strike.Capabilities)
+	}
+}
@@ -0,0 +1,109 @@
+package rabbitmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// eventually polls cond until it holds or a second has passed
+func eventually(t *testing.T, cond func() bool, msg string) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatal(msg)
+}
+
+func TestConsumeStatusUpdates_MemoryBroker(t *testing.T) {
+	b := broker.NewMemory()
+	if err := b.Declare(Topology()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Status: models.StatusQueued, Attempt: 1})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go ConsumeStatusUpdates(ctx, b, missions, 1024)
+	go ConsumeDeadLetters(ctx, b, missions)
+
+	update, _ := json.Marshal(models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1, Attempt: 1})
+	if err := b.Publish("", StatusQueue, true, amqp.Publishing{ContentType: "application/json", Body: update}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	eventually(t, func() bool {
+		mission, _ := missions.Get("m1")
+		return mission.Status == models.StatusInProgress
+	}, "expected the status update to be applied")
+
+	// Malformed updates are dead-lettered and end up in the dead letter store
+	b.Publish("", StatusQueue, true, amqp.Publishing{Body: []byte("not json")})
+	eventually(t, func() bool {
+		letters, _ := missions.DeadLetters(StatusQueue)
+		return len(letters) == 1 && letters[0].Body == "not json" && strings.HasPrefix(letters[0].Reason, "malformed message")
+	}, "expected the malformed update to be stored as a dead letter")
+	if b.Len(StatusQueue) != 0 || b.Len(DeadLetterQueue) != 0 {
+		t.Fatalf("expected every message to be settled")
+	}
+}
+
+func TestPublishMission_MemoryBroker(t *testing.T) {
+	b := broker.NewMemory()
+	b.Declare(Topology())
+
+	missions := store.NewMemoryStore()
+	mission := &models.Mission{MissionID: "m1", Priority: 7}
+	if err := PublishMission(context.Background(), b, missions, nil, mission); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, ok := b.Get(OrdersQueue)
+	if !ok || d.Priority != 7 || d.DeliveryMode != amqp.Persistent {
+		t.Fatalf("expected the persistent mission in %s with its priority, got %+v", OrdersQueue, d)
+	}
+
+	// No soldier queue is bound for this soldier yet
+	targeted := &models.Mission{MissionID: "m2", Target: models.TargetSoldierPrefix + "s1"}
+	if err := PublishMission(context.Background(), b, missions, nil, targeted); !errors.Is(err, broker.ErrUnroutable) {
+		t.Fatalf("expected the targeted mission to be unroutable, got %v", err)
+	}
+}
+
+// nackingBroker fails every publish with errNacked
+type nackingBroker struct {
+	broker.Broker
+	publishes int
+}
+
+var errNacked = errors.New("nacked")
+
+func (b *nackingBroker) Publish(exchange, key string, mandatory bool, msg amqp.Publishing) error {
+	b.publishes++
+	return errNacked
+}
+
+func TestPublishMission_StopsRetryingWithContext(t *testing.T) {
+	b := &nackingBroker{Broker: broker.NewMemory()}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	err := PublishMission(ctx, b, store.NewMemoryStore(), nil, &models.Mission{MissionID: "m1"})
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.Is(err, errNacked) {
+		t.Fatalf("expected the deadline and the last failure, got %v", err)
+	}
+	if b.publishes != 1 || time.Since(started) > time.Second {
+		t.Fatalf("expected to stop waiting for the retry, got %d publishes after %v", b.publishes, time.Since(started))
+	}
+}
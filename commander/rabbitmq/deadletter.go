@@ -0,0 +1,138 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"log"
+	"maps"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ReplayedHeader carries the ID of the dead letter a replayed message came from
+const ReplayedHeader = "x-replayed-dead-letter"
+
+// errMalformedMessage marks messages that can never be processed, such as invalid JSON
+var errMalformedMessage = errors.New("malformed message")
+
+// settle acknowledges a processed message. Messages that can never be applied
+// are dead-lettered with the error as their reason; unexpected failures are
+// requeued once and dead-lettered when they fail again. Stale updates and
+// invalid transitions are expected with redeliveries and are simply acknowledged.
+func settle(b broker.Broker, d amqp.Delivery, err error) {
+	switch {
+	case err == nil, errors.Is(err, models.ErrStaleUpdate), errors.Is(err, models.ErrInvalidTransition):
+		d.Ack(false)
+	case errors.Is(err, errMalformedMessage), errors.Is(err, store.ErrNotFound):
+		log.Printf("Dead-lettering message from %s: %v", d.RoutingKey, err)
+		deadLetter(b, d, err)
+	case d.Redelivered:
+		log.Printf("Dead-lettering message from %s after repeated failure: %v", d.RoutingKey, err)
+		deadLetter(b, d, err)
+	default:
+		log.Printf("Requeueing message from %s: %v", d.RoutingKey, err)
+		d.Nack(false, true)
+	}
+}
+
+// deadLetter dead-letters a status update with the reason it failed
+func deadLetter(b broker.Broker, d amqp.Delivery, reason error) {
+	if err := broker.DeadLetter(b, DeadLetterExchange, StatusQueue, d, reason); err != nil {
+		log.Printf("Failed to dead-letter message from %s with its reason, rejected instead: %v", d.RoutingKey, err)
+	}
+}
+
+// ConsumeDeadLetters moves every dead-lettered message into the store, where
+// it can be inspected, replayed or purged through the admin API
+func ConsumeDeadLetters(ctx context.Context, b broker.Broker, letters store.DeadLetterStore) {
+	msgs := b.Consume(ctx, broker.Consumer{Queues: []string{DeadLetterQueue}})
+
+	for d := range msgs {
+		letter := deadLetterFrom(d)
+		if err := letters.AddDeadLetter(&letter); err != nil {
+			log.Printf("Failed to store dead letter from %s: %v", letter.Queue, err)
+			// Back off so a failing store is not retried in a tight loop
+			time.Sleep(time.Second)
+			d.Nack(false, true)
+			continue
+		}
+		log.Printf("Dead letter %d from %s stored (%s)", letter.ID, letter.Queue, letter.Reason)
+		d.Ack(false)
+	}
+}
+
+// deadLetterFrom describes a delivery from DeadLetterQueue. Messages the
+// services dead-letter themselves carry the reason and their origin in the
+// x-failed headers; for the rest it uses the x-death header RabbitMQ adds.
+func deadLetterFrom(d amqp.Delivery) models.DeadLetter {
+	letter := models.DeadLetter{
+		Queue:          d.RoutingKey,
+		RoutingKey:     d.RoutingKey,
+		DeadLetteredAt: time.Now().UTC(),
+		ContentType:    d.ContentType,
+		Priority:       d.Priority,
+		Headers:        d.Headers,
+		Body:           string(d.Body),
+	}
+	if reason, ok := d.Headers[broker.FailureReasonHeader].(string); ok {
+		letter.Reason = reason
+		letter.Count = 1
+		letter.Queue, _ = d.Headers[broker.FailedQueueHeader].(string)
+		letter.Exchange, _ = d.Headers[broker.FailedExchangeHeader].(string)
+		letter.RoutingKey, _ = d.Headers[broker.FailedRoutingKeyHeader].(string)
+		if !d.Timestamp.IsZero() {
+			letter.DeadLetteredAt = d.Timestamp.UTC()
+		}
+		return letter
+	}
+	// The most recent death comes first
+	deaths, _ := d.Headers["x-death"].([]any)
+	if len(deaths) == 0 {
+		return letter
+	}
+	death, _ := deaths[0].(amqp.Table)
+	letter.Queue, _ = death["queue"].(string)
+	letter.Exchange, _ = death["exchange"].(string)
+	letter.Reason, _ = death["reason"].(string)
+	letter.Count, _ = death["count"].(int64)
+	if keys, _ := death["routing-keys"].([]any); len(keys) > 0 {
+		letter.RoutingKey, _ = keys[0].(string)
+	}
+	if at, ok := death["time"].(time.Time); ok {
+		letter.DeadLetteredAt = at.UTC()
+	}
+	return letter
+}
+
+// deathHeaders are the headers dead-lettering adds to a message; they are
+// dropped on replay so the message looks as it was first published
+var deathHeaders = []string{
+	"x-death", "x-first-death-queue", "x-first-death-reason", "x-first-death-exchange",
+	"x-last-death-queue", "x-last-death-reason", "x-last-death-exchange",
+	broker.FailureReasonHeader, broker.FailedQueueHeader, broker.FailedExchangeHeader, broker.FailedRoutingKeyHeader,
+}
+
+// ReplayDeadLetter republishes a dead letter with the exchange, routing key
+// and headers it was originally published with, marked as a replay, and waits
+// for the broker to confirm it
+func ReplayDeadLetter(publisher broker.Broker, letter *models.DeadLetter) error {
+	headers := amqp.Table{}
+	maps.Copy(headers, letter.Headers)
+	for _, h := range deathHeaders {
+		delete(headers, h)
+	}
+	headers[ReplayedHeader] = int64(letter.ID)
+
+	return publisher.Publish(letter.Exchange, letter.RoutingKey, true, amqp.Publishing{
+		ContentType:  letter.ContentType,
+		DeliveryMode: amqp.Persistent,
+		Priority:     letter.Priority,
+		Headers:      headers,
+		Body:         []byte(letter.Body),
+	})
+}
@@ -0,0 +1,149 @@
+package rabbitmq
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// acknowledger records how a delivery was settled
+type acknowledger struct {
+	acked, nacked, requeued bool
+}
+
+func (a *acknowledger) Ack(tag uint64, multiple bool) error {
+	a.acked = true
+	return nil
+}
+
+func (a *acknowledger) Nack(tag uint64, multiple, requeue bool) error {
+	a.nacked, a.requeued = true, requeue
+	return nil
+}
+
+func (a *acknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func TestSettle(t *testing.T) {
+	cases := []struct {
+		name         string
+		err          error
+		redelivered  bool
+		expected     acknowledger
+		deadLettered bool
+	}{
+		{"applied", nil, false, acknowledger{acked: true}, false},
+		{"stale", fmt.Errorf("%w: seq 1", models.ErrStaleUpdate), false, acknowledger{acked: true}, false},
+		{"invalid transition", fmt.Errorf("%w: COMPLETED -> QUEUED", models.ErrInvalidTransition), false, acknowledger{acked: true}, false},
+		{"malformed", errMalformedMessage, false, acknowledger{acked: true}, true},
+		{"unknown mission", store.ErrNotFound, false, acknowledger{acked: true}, true},
+		{"first failure", errors.New("disk full"), false, acknowledger{nacked: true, requeued: true}, false},
+		{"repeated failure", errors.New("disk full"), true, acknowledger{acked: true}, true},
+	}
+	for _, c := range cases {
+		b := broker.NewMemory()
+		b.Declare(Topology())
+
+		ack := &acknowledger{}
+		settle(b, amqp.Delivery{Acknowledger: ack, RoutingKey: StatusQueue, Redelivered: c.redelivered, Body: []byte("{}")}, c.err)
+		if *ack != c.expected {
+			t.Fatalf("%s: expected %+v, got %+v", c.name, c.expected, *ack)
+		}
+		letter, ok := b.Get(DeadLetterQueue)
+		if ok != c.deadLettered {
+			t.Fatalf("%s: expected dead-lettered %v, got %v", c.name, c.deadLettered, ok)
+		}
+		if ok && (letter.Headers[broker.FailureReasonHeader] != c.err.Error() || letter.Headers[broker.FailedQueueHeader] != StatusQueue) {
+			t.Fatalf("%s: expected the reason in the dead letter, got %v", c.name, letter.Headers)
+		}
+	}
+
+	// Without a dead-letter exchange the update is rejected so the queue dead-letters it
+	ack := &acknowledger{}
+	settle(broker.NewMemory(), amqp.Delivery{Acknowledger: ack}, errMalformedMessage)
+	if *ack != (acknowledger{nacked: true}) {
+		t.Fatalf("expected the update to be rejected, got %+v", *ack)
+	}
+}
+
+func TestDeadLetterFrom(t *testing.T) {
+	died := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	d := amqp.Delivery{
+		Exchange:    DeadLetterExchange,
+		RoutingKey:  OrdersQueue,
+		ContentType: "application/json",
+		Priority:    5,
+		Body:        []byte("{not json"),
+		Headers: amqp.Table{
+			"x-death": []any{amqp.Table{
+				"queue":        OrdersQueue,
+				"exchange":     "",
+				"reason":       "rejected",
+				"count":        int64(2),
+				"routing-keys": []any{OrdersQueue},
+				"time":         died,
+			}},
+		},
+	}
+
+	letter := deadLetterFrom(d)
+	if letter.Queue != OrdersQueue || letter.Exchange != "" || letter.RoutingKey != OrdersQueue {
+		t.Fatalf("expected the original route, got %+v", letter)
+	}
+	if letter.Reason != "rejected" || letter.Count != 2 || !letter.DeadLetteredAt.Equal(died) {
+		t.Fatalf("expected x-death details, got %+v", letter)
+	}
+	if letter.Body != "{not json" || letter.Priority != 5 {
+		t.Fatalf("expected the message to be kept as is, got %+v", letter)
+	}
+}
+
+func TestDeadLetterFrom_FailureReason(t *testing.T) {
+	b := broker.NewMemory()
+	b.Declare(Topology())
+	d := amqp.Delivery{
+		Acknowledger: &acknowledger{},
+		Exchange:     OrdersExchange,
+		RoutingKey:   "soldier.s1",
+		Priority:     3,
+		Headers:      amqp.Table{"trace": "abc"},
+		Body:         []byte(`{"id":""}`),
+	}
+	if err := broker.DeadLetter(b, DeadLetterExchange, "soldier.s1", d, errors.New("empty mission ID")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dead, _ := b.Get(DeadLetterQueue)
+
+	letter := deadLetterFrom(dead)
+	if letter.Reason != "empty mission ID" || letter.Count != 1 {
+		t.Fatalf("expected the failure reason, got %+v", letter)
+	}
+	if letter.Queue != "soldier.s1" || letter.Exchange != OrdersExchange || letter.RoutingKey != "soldier.s1" || letter.Priority != 3 {
+		t.Fatalf("expected the original route, got %+v", letter)
+	}
+
+	// The replay keeps application headers but drops the dead-lettering ones
+	letter.ID = 7
+	letter.Headers["x-death"] = []any{amqp.Table{"reason": "rejected"}}
+	b.Declare(broker.Topology{
+		Queues:   []broker.Queue{{Name: "soldier.s1"}},
+		Bindings: []broker.Binding{{Queue: "soldier.s1", Key: "soldier.s1", Exchange: OrdersExchange}},
+	})
+	if err := ReplayDeadLetter(b, &letter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replayed, _ := b.Get("soldier.s1")
+	expected := amqp.Table{"trace": "abc", ReplayedHeader: int64(7)}
+	if !reflect.DeepEqual(replayed.Headers, expected) {
+		t.Fatalf("expected headers %v, got %v", expected, replayed.Headers)
+	}
+}
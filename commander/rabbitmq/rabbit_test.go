@@ -0,0 +1,209 @@
+package rabbitmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"mission_control/commander/models"
+	"mission_control/commander/registry"
+	"mission_control/commander/store"
+)
+
+func newQueuedMission() store.MissionStore {
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Order: "Recon", Status: models.StatusQueued})
+	return missions
+}
+
+func TestSaveMissionStatus_Lifecycle(t *testing.T) {
+	missions := newQueuedMission()
+
+	for seq, status := range []string{models.StatusInProgress, models.StatusCompleted} {
+		update := models.StatusUpdate{MissionID: "m1", Status: status, Seq: int64(seq + 1)}
+		if err := SaveMissionStatus(missions, update, models.SourceSoldier); err != nil {
+			t.Fatalf("expected %s to be accepted, got %v", status, err)
+		}
+	}
+
+	events, _ := missions.Events("m1")
+	if len(events) != 2 {
+		t.Fatalf("expected 2 recorded transitions, got %d", len(events))
+	}
+}
+
+func TestSaveMissionStatus_RejectsRegression(t *testing.T) {
+	missions := newQueuedMission()
+	SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusCompleted, Seq: 2}, models.SourceSoldier)
+
+	// Terminal missions cannot move back to QUEUED or IN_PROGRESS
+	err := SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusQueued}, models.SourceCommander)
+	if !errors.Is(err, models.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+
+	// A late redelivery of an older update is stale
+	err = SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1}, models.SourceSoldier)
+	if !errors.Is(err, models.ErrStaleUpdate) {
+		t.Fatalf("expected ErrStaleUpdate, got %v", err)
+	}
+
+	got, _ := missions.Get("m1")
+	if got.Status != models.StatusCompleted {
+		t.Fatalf("expected COMPLETED to be kept, got %s", got.Status)
+	}
+	if events, _ := missions.Events("m1"); len(events) != 1 {
+		t.Fatalf("rejected updates must not be recorded, got %d events", len(events))
+	}
+}
+
+func TestSaveMissionStatus_UnknownMissionAndStatus(t *testing.T) {
+	missions := newQueuedMission()
+
+	err := SaveMissionStatus(missions, models.StatusUpdate{MissionID: "unknown", Status: models.StatusInProgress}, models.SourceSoldier)
+	if err != store.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	err = SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: "DANCING"}, models.SourceSoldier)
+	if !errors.Is(err, models.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+}
+
+func TestSaveMissionStatus_StartsDeadline(t *testing.T) {
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Status: models.StatusQueued, Attempt: 1, TimeoutSeconds: 60})
+
+	SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1, Attempt: 1}, models.SourceSoldier)
+
+	got, _ := missions.Get("m1")
+	if got.Deadline == nil || time.Until(*got.Deadline) < 59*time.Second {
+		t.Fatalf("expected deadline about 60s ahead, got %v", got.Deadline)
+	}
+}
+
+func TestSaveMissionStatus_StoresOutcome(t *testing.T) {
+	missions := newQueuedMission()
+	started := time.Now().UTC()
+	finished := started.Add(time.Second)
+
+	SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1, StartedAt: &started}, models.SourceSoldier)
+	failed := models.StatusUpdate{
+		MissionID:  "m1",
+		Status:     models.StatusFailed,
+		Seq:        2,
+		Error:      &models.MissionError{Code: "TARGET_NOT_FOUND", Message: "nothing at the given coordinates"},
+		FinishedAt: &finished,
+	}
+	if err := SaveMissionStatus(missions, failed, models.SourceSoldier); err != nil {
+		t.Fatalf("expected FAILED to be accepted, got %v", err)
+	}
+
+	mission, _ := missions.Get("m1")
+	if mission.Error == nil || mission.Error.Code != "TARGET_NOT_FOUND" {
+		t.Fatalf("expected stored error, got %+v", mission.Error)
+	}
+	if mission.StartedAt == nil || !mission.StartedAt.Equal(started) || mission.FinishedAt == nil || !mission.FinishedAt.Equal(finished) {
+		t.Fatalf("expected both timings, got %v %v", mission.StartedAt, mission.FinishedAt)
+	}
+}
+
+func TestSaveMissionProgress(t *testing.T) {
+	missions := newQueuedMission()
+	progress := models.StatusUpdate{MissionID: "m1", Status: models.StatusProgress, Seq: 2, Progress: &models.Progress{Percent: 40, Message: "halfway"}}
+
+	// Progress is only accepted while the mission runs
+	if err := SaveMissionProgress(missions, progress); !errors.Is(err, models.ErrInvalidTransition) {
+		t.Fatalf("expected progress of a QUEUED mission to be rejected, got %v", err)
+	}
+
+	SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1}, models.SourceSoldier)
+	if err := SaveMissionProgress(missions, progress); err != nil {
+		t.Fatalf("expected progress to be accepted, got %v", err)
+	}
+	if err := SaveMissionProgress(missions, progress); !errors.Is(err, models.ErrStaleUpdate) {
+		t.Fatalf("expected redelivered progress to be rejected, got %v", err)
+	}
+
+	mission, _ := missions.Get("m1")
+	if mission.Status != models.StatusInProgress || mission.Progress == nil || mission.Progress.Percent != 40 {
+		t.Fatalf("expected IN_PROGRESS mission at 40%%, got %s %+v", mission.Status, mission.Progress)
+	}
+	if events, _ := missions.Events("m1"); len(events) != 1 {
+		t.Fatalf("progress must not be recorded as a transition, got %d events", len(events))
+	}
+
+	// The final status still follows the progress sequence
+	if err := SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusCompleted, Seq: 3}, models.SourceSoldier); err != nil {
+		t.Fatalf("expected COMPLETED after progress, got %v", err)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	cases := map[string][2]string{
+		"":                {"", OrdersQueue},
+		"any":             {"", OrdersQueue},
+		"soldier:alpha-1": {OrdersExchange, "soldier.alpha-1"},
+		"unit:bravo":      {OrdersExchange, "unit.bravo"},
+	}
+	for target, expected := range cases {
+		exchange, key, err := Route(nil, &models.Mission{Target: target})
+		if err != nil || exchange != expected[0] || key != expected[1] {
+			t.Fatalf("%q: expected %v, got %q %q %v", target, expected, exchange, key, err)
+		}
+	}
+
+	// Dots and wildcards would match other soldiers' bindings
+	for _, target := range []string{"soldier:", "unit:a.b", "unit:#", "squad:alpha"} {
+		if _, _, err := Route(nil, &models.Mission{Target: target}); !errors.Is(err, models.ErrInvalidTarget) {
+			t.Fatalf("%q: expected ErrInvalidTarget, got %v", target, err)
+		}
+	}
+
+	// Missions requiring capabilities go to a capable soldier's own queue
+	soldiers := registry.New(15*time.Second, time.Minute)
+	soldiers.Record(models.Heartbeat{SoldierID: "s1", Capabilities: []string{"strike"}}, time.Now().UTC())
+	exchange, key, err := Route(soldiers, &models.Mission{Capabilities: []string{"strike"}})
+	if err != nil || exchange != OrdersExchange || key != "soldier.s1" {
+		t.Fatalf("expected soldier.s1, got %q %q %v", exchange, key, err)
+	}
+	if _, _, err := Route(soldiers, &models.Mission{Capabilities: []string{"medic"}}); !errors.Is(err, registry.ErrNoCapableSoldier) {
+		t.Fatalf("expected ErrNoCapableSoldier, got %v", err)
+	}
+}
+
+func TestSaveMissionStatus_RetriesFailedAttempt(t *testing.T) {
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Status: models.StatusQueued, Attempt: 1, MaxAttempts: 2, Backoff: &models.Backoff{Type: models.BackoffFixed, InitialSeconds: 30}})
+	failed := models.StatusUpdate{MissionID: "m1", Status: models.StatusFailed, Seq: 2, Attempt: 1, Error: &models.MissionError{Code: models.StatusFailed}}
+
+	SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1, Attempt: 1}, models.SourceSoldier)
+	if err := SaveMissionStatus(missions, failed, models.SourceSoldier); err != nil {
+		t.Fatalf("expected FAILED to be accepted, got %v", err)
+	}
+
+	// The first failure leaves another attempt, which waits for its backoff
+	mission, _ := missions.Get("m1")
+	if mission.Status != models.StatusRetryPending || mission.RetryAt == nil || time.Until(*mission.RetryAt) < 29*time.Second {
+		t.Fatalf("expected RETRY_PENDING about 30s ahead, got %s %v", mission.Status, mission.RetryAt)
+	}
+	if len(mission.Attempts) != 1 || mission.Attempts[0].Status != models.StatusFailed || mission.Attempts[0].Error == nil {
+		t.Fatalf("expected the failed attempt to be recorded, got %+v", mission.Attempts)
+	}
+
+	requeued, err := RequeueMission(missions, "m1", models.SourceCommander)
+	if err != nil || requeued.Attempt != 2 || requeued.Error != nil || requeued.RetryAt != nil {
+		t.Fatalf("expected a clean second attempt, got %+v %v", requeued, err)
+	}
+
+	// The last attempt's failure is final
+	failed.Attempt = 2
+	SaveMissionStatus(missions, models.StatusUpdate{MissionID: "m1", Status: models.StatusInProgress, Seq: 1, Attempt: 2}, models.SourceSoldier)
+	SaveMissionStatus(missions, failed, models.SourceSoldier)
+
+	mission, _ = missions.Get("m1")
+	if mission.Status != models.StatusFailed || len(mission.Attempts) != 2 || mission.Attempts[1].Attempt != 2 {
+		t.Fatalf("expected FAILED after two attempts, got %s %+v", mission.Status, mission.Attempts)
+	}
+}
@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"mission_control/commander/models"
+)
+
+var (
+	// ErrNotFound is returned for soldiers that never registered or were forgotten
+	ErrNotFound = errors.New("soldier not found")
+	// ErrNoCapableSoldier is returned when no registered soldier can serve a mission
+	ErrNoCapableSoldier = errors.New("no registered soldier has the required capabilities")
+)
+
+// forgetAfter is how long OFFLINE soldiers stay listed before they are dropped
+const forgetAfter = 24 * time.Hour
+
+// Registry keeps the soldiers known from their heartbeats. It lives in
+// memory only: after a commander restart soldiers re-appear with their next
+// heartbeat.
+type Registry struct {
+	mu           sync.RWMutex
+	soldiers     map[string]*models.Soldier
+	staleAfter   time.Duration
+	offlineAfter time.Duration
+	started      time.Time
+}
+
+// New returns an empty registry. Soldiers silent for longer than staleAfter
+// are STALE, and OFFLINE after offlineAfter.
+func New(staleAfter, offlineAfter time.Duration) *Registry {
+	return &Registry{
+		soldiers:     make(map[string]*models.Soldier),
+		staleAfter:   staleAfter,
+		offlineAfter: offlineAfter,
+		started:      time.Now().UTC(),
+	}
+}
+
+// Record applies a heartbeat received at now. Heartbeats from unknown
+// soldiers register them, so soldiers reappear after a commander restart.
+func (r *Registry) Record(hb models.Heartbeat, now time.Time) error {
+	if hb.SoldierID == "" {
+		return errors.New("heartbeat without soldier_id")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	soldier, ok := r.soldiers[hb.SoldierID]
+	if !ok || hb.Type == models.HeartbeatRegister {
+		soldier = &models.Soldier{SoldierID: hb.SoldierID, RegisteredAt: now}
+		r.soldiers[hb.SoldierID] = soldier
+	}
+	if hb.Version != "" {
+		soldier.Version = hb.Version
+	}
+	if hb.Hostname != "" {
+		soldier.Hostname = hb.Hostname
+	}
+	if hb.Unit != "" {
+		soldier.Unit = hb.Unit
+	}
+	if hb.Capabilities != nil {
+		soldier.Capabilities = slices.Clone(hb.Capabilities)
+	}
+	soldier.Missions = slices.Clone(hb.Missions)
+	soldier.Workers, soldier.BusyWorkers = hb.Workers, hb.BusyWorkers
+	soldier.LastSeen = now
+	soldier.Deregistered = hb.Type == models.HeartbeatDeregister
+	if soldier.Deregistered {
+		soldier.Missions = nil
+		soldier.BusyWorkers = 0
+	}
+	return nil
+}
+
+// Get returns a copy of the soldier with its state as of now
+func (r *Registry) Get(id string, now time.Time) (*models.Soldier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	soldier, ok := r.soldiers[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return r.snapshot(soldier, now), nil
+}
+
+// Lost reports whether orders routed to the soldier's own queue at since may
+// have been deleted with it: the soldier is OFFLINE as of now, registered
+// again after since, or unknown although the registry has been up long
+// enough to have heard its heartbeats.
+func (r *Registry) Lost(id string, since, now time.Time) bool {
+	soldier, err := r.Get(id, now)
+	if err != nil {
+		return now.Sub(r.started) > r.offlineAfter
+	}
+	// Soldiers first heard of after a commander restart were not re-registered
+	restarted := since.After(r.started) && soldier.RegisteredAt.After(since)
+	return soldier.State == models.SoldierOffline || restarted
+}
+
+// List returns copies of all soldiers, sorted by ID, with their state as of
+// now. Soldiers offline for longer than forgetAfter are dropped.
+func (r *Registry) List(now time.Time) []*models.Soldier {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	soldiers := make([]*models.Soldier, 0, len(r.soldiers))
+	for id, soldier := range r.soldiers {
+		if now.Sub(soldier.LastSeen) > forgetAfter {
+			delete(r.soldiers, id)
+			continue
+		}
+		soldiers = append(soldiers, r.snapshot(soldier, now))
+	}
+	slices.SortFunc(soldiers, func(a, b *models.Soldier) int { return strings.Compare(a.SoldierID, b.SoldierID) })
+	return soldiers
+}
+
+// CanServe reports whether any registered soldier, whatever its current
+// state, matches the mission's target and has all its required capabilities
+func (r *Registry) CanServe(mission *models.Mission) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, soldier := range r.soldiers {
+		if matchesTarget(soldier, mission.Target) && soldier.HasCapabilities(mission.Capabilities) {
+			return true
+		}
+	}
+	return false
+}
+
+// Pick chooses the soldier to run a mission that requires capabilities:
+// among the soldiers matching its target and capabilities it prefers ONLINE
+// over STALE over OFFLINE ones, then those with a free worker, then the
+// fewest running missions, then the most recent heartbeat.
+func (r *Registry) Pick(mission *models.Mission, now time.Time) (*models.Soldier, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best *models.Soldier
+	for _, soldier := range r.soldiers {
+		if !matchesTarget(soldier, mission.Target) || !soldier.HasCapabilities(mission.Capabilities) {
+			continue
+		}
+		candidate := r.snapshot(soldier, now)
+		if best == nil || better(candidate, best) {
+			best = candidate
+		}
+	}
+	if best == nil {
+		return nil, ErrNoCapableSoldier
+	}
+	return best, nil
+}
+
+// stateRank orders soldier states from most to least preferred
+var stateRank = map[string]int{models.SoldierOnline: 0, models.SoldierStale: 1, models.SoldierOffline: 2}
+
+// better reports whether soldier a should get a mission rather than b
+func better(a, b *models.Soldier) bool {
+	if stateRank[a.State] != stateRank[b.State] {
+		return stateRank[a.State] < stateRank[b.State]
+	}
+	if a.HasFreeWorker() != b.HasFreeWorker() {
+		return a.HasFreeWorker()
+	}
+	if len(a.Missions) != len(b.Missions) {
+		return len(a.Missions) < len(b.Missions)
+	}
+	return a.LastSeen.After(b.LastSeen)
+}
+
+// matchesTarget reports whether the soldier is covered by a mission target
+func matchesTarget(soldier *models.Soldier, target string) bool {
+	prefix, name, err := models.ParseTarget(target)
+	if err != nil {
+		return false
+	}
+	switch prefix {
+	case models.TargetSoldierPrefix:
+		return soldier.SoldierID == name
+	case models.TargetUnitPrefix:
+		return soldier.Unit == name
+	}
+	return true
+}
+
+// snapshot copies the soldier and derives its state from the last heartbeat
+func (r *Registry) snapshot(soldier *models.Soldier, now time.Time) *models.Soldier {
+	s := *soldier
+	s.Missions = slices.Clone(soldier.Missions)
+	if s.Missions == nil {
+		s.Missions = []string{}
+	}
+	s.Capabilities = slices.Clone(soldier.Capabilities)
+	if s.Capabilities == nil {
+		s.Capabilities = []string{}
+	}
+	silence := now.Sub(s.LastSeen)
+	switch {
+	case s.Deregistered || silence > r.offlineAfter:
+		s.State = models.SoldierOffline
+		s.Missions = []string{}
+		s.BusyWorkers = 0
+	case silence > r.staleAfter:
+		s.State = models.SoldierStale
+	default:
+		s.State = models.SoldierOnline
+	}
+	return &s
+}
@@ -0,0 +1,134 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"mission_control/commander/models"
+)
+
+func TestRegistry_States(t *testing.T) {
+	r := New(15*time.Second, time.Minute)
+	start := time.Now()
+
+	r.Record(models.Heartbeat{Type: models.HeartbeatRegister, SoldierID: "s1", Version: "1.2.0", Hostname: "host-a"}, start)
+	r.Record(models.Heartbeat{Type: models.HeartbeatBeat, SoldierID: "s1", Missions: []string{"m1"}}, start.Add(5*time.Second))
+
+	cases := map[time.Duration]string{
+		10 * time.Second: models.SoldierOnline,
+		30 * time.Second: models.SoldierStale,
+		2 * time.Minute:  models.SoldierOffline,
+	}
+	for after, expected := range cases {
+		soldier, err := r.Get("s1", start.Add(after))
+		if err != nil {
+			t.Fatalf("expected registered soldier: %v", err)
+		}
+		if soldier.State != expected {
+			t.Fatalf("after %v: expected %s, got %s", after, expected, soldier.State)
+		}
+	}
+
+	soldier, _ := r.Get("s1", start.Add(10*time.Second))
+	if soldier.Version != "1.2.0" || soldier.Hostname != "host-a" || len(soldier.Missions) != 1 {
+		t.Fatalf("heartbeat must keep registration details and update missions, got %+v", soldier)
+	}
+	if !soldier.RegisteredAt.Equal(start) {
+		t.Fatalf("heartbeat must not reset registration time")
+	}
+}
+
+func TestRegistry_DeregisterAndForget(t *testing.T) {
+	r := New(15*time.Second, time.Minute)
+	now := time.Now()
+
+	// Heartbeats from unknown soldiers register them
+	r.Record(models.Heartbeat{Type: models.HeartbeatBeat, SoldierID: "s2", Missions: []string{"m1"}}, now)
+	r.Record(models.Heartbeat{Type: models.HeartbeatDeregister, SoldierID: "s2"}, now)
+
+	soldier, err := r.Get("s2", now)
+	if err != nil || soldier.State != models.SoldierOffline || len(soldier.Missions) != 0 {
+		t.Fatalf("expected deregistered soldier to be OFFLINE without missions, got %+v %v", soldier, err)
+	}
+
+	if list := r.List(now.Add(forgetAfter + time.Second)); len(list) != 0 {
+		t.Fatalf("expected long offline soldier to be forgotten, got %d", len(list))
+	}
+	if _, err := r.Get("s2", now); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRegistry_CanServeAndPick(t *testing.T) {
+	r := New(15*time.Second, time.Minute)
+	now := time.Now()
+	r.Record(models.Heartbeat{SoldierID: "busy", Unit: "alpha", Capabilities: []string{"recon", "strike"}, Missions: []string{"m1", "m2"}}, now)
+	r.Record(models.Heartbeat{SoldierID: "idle", Unit: "alpha", Capabilities: []string{"recon", "strike"}}, now)
+	r.Record(models.Heartbeat{SoldierID: "gone", Unit: "bravo", Capabilities: []string{"recon", "strike"}}, now.Add(-10*time.Minute))
+	r.Record(models.Heartbeat{SoldierID: "scout", Unit: "bravo", Capabilities: []string{"recon"}}, now)
+
+	strike := &models.Mission{Capabilities: []string{"strike"}}
+	soldier, err := r.Pick(strike, now)
+	if err != nil || soldier.SoldierID != "idle" {
+		t.Fatalf("expected the idle ONLINE soldier, got %+v %v", soldier, err)
+	}
+
+	// In unit bravo only an OFFLINE soldier can strike: it can still serve, and is picked as last resort
+	bravo := &models.Mission{Capabilities: []string{"strike"}, Target: "unit:bravo"}
+	if !r.CanServe(bravo) {
+		t.Fatalf("expected an OFFLINE but registered soldier to count as able to serve")
+	}
+	if soldier, err := r.Pick(bravo, now); err != nil || soldier.SoldierID != "gone" {
+		t.Fatalf("expected the OFFLINE soldier, got %+v %v", soldier, err)
+	}
+
+	impossible := &models.Mission{Capabilities: []string{"strike"}, Target: "soldier:scout"}
+	if r.CanServe(impossible) {
+		t.Fatalf("scout cannot strike")
+	}
+	if _, err := r.Pick(impossible, now); err != ErrNoCapableSoldier {
+		t.Fatalf("expected ErrNoCapableSoldier, got %v", err)
+	}
+}
+
+func TestRegistry_PickPrefersFreeWorkers(t *testing.T) {
+	r := New(15*time.Second, time.Minute)
+	now := time.Now()
+	// full runs fewer missions but every one of its workers is busy
+	r.Record(models.Heartbeat{SoldierID: "full", Capabilities: []string{"recon"}, Missions: []string{"m1"}, Workers: 1, BusyWorkers: 1}, now)
+	r.Record(models.Heartbeat{SoldierID: "spare", Capabilities: []string{"recon"}, Missions: []string{"m2", "m3"}, Workers: 4, BusyWorkers: 2}, now)
+
+	soldier, err := r.Pick(&models.Mission{Capabilities: []string{"recon"}}, now)
+	if err != nil || soldier.SoldierID != "spare" {
+		t.Fatalf("expected the soldier with a free worker, got %+v %v", soldier, err)
+	}
+	if soldier.Workers != 4 || soldier.BusyWorkers != 2 {
+		t.Fatalf("expected the reported utilisation, got %d of %d workers busy", soldier.BusyWorkers, soldier.Workers)
+	}
+}
+
+func TestRegistry_Lost(t *testing.T) {
+	r := New(15*time.Second, time.Minute)
+	routed := time.Now().UTC().Add(time.Second)
+	r.Record(models.Heartbeat{Type: models.HeartbeatRegister, SoldierID: "s1"}, routed.Add(-time.Second))
+
+	if r.Lost("s1", routed, routed.Add(10*time.Second)) {
+		t.Fatal("orders of an ONLINE soldier are not lost")
+	}
+	if !r.Lost("s1", routed, routed.Add(2*time.Minute)) {
+		t.Fatal("orders of an OFFLINE soldier are lost")
+	}
+	// A restart deletes the soldier's queue even when it keeps its ID and never goes OFFLINE
+	r.Record(models.Heartbeat{Type: models.HeartbeatRegister, SoldierID: "s1"}, routed.Add(5*time.Second))
+	if !r.Lost("s1", routed, routed.Add(10*time.Second)) {
+		t.Fatal("orders routed before the soldier registered again are lost")
+	}
+
+	// Unknown soldiers count as lost only once the registry would have heard them
+	if r.Lost("s9", routed, routed.Add(10*time.Second)) {
+		t.Fatal("unknown soldiers may just not have sent a heartbeat yet")
+	}
+	if !r.Lost("s9", routed, routed.Add(2*time.Minute)) {
+		t.Fatal("soldiers unheard of for longer than the offline window are lost")
+	}
+}
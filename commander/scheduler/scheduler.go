@@ -0,0 +1,113 @@
+package scheduler
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
+	"mission_control/commander/store"
+)
+
+// Scheduler publishes SCHEDULED missions once their execute_at time has come
+// and republishes RETRY_PENDING missions as a new attempt once their backoff
+// has passed. All state lives in the mission store, so with a persistent store
+// both survive commander restarts and are picked up again on start.
+type Scheduler struct {
+	missions store.MissionStore
+	publish  func(ctx context.Context, mission *models.Mission) error
+	interval time.Duration
+}
+
+// New returns a scheduler that publishes due missions with publisher every interval,
+// routing missions that require capabilities to a soldier from soldiers
+func New(publisher broker.Broker, missions store.MissionStore, soldiers *registry.Registry, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		missions: missions,
+		publish: func(ctx context.Context, mission *models.Mission) error {
+			return rabbitmq.PublishMission(ctx, publisher, missions, soldiers, mission)
+		},
+		interval: interval,
+	}
+}
+
+// Run publishes due and retried missions on every tick until ctx is done
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	log.Println("Mission scheduler started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Mission scheduler stopped")
+			return
+		case now := <-ticker.C:
+			s.publishDue(ctx, now)
+			s.retryDue(ctx, now)
+		}
+	}
+}
+
+// publishDue moves every due mission to QUEUED and publishes it. The status
+// change happens first so a concurrent cancel either wins or is broadcast
+// after the mission reached the queue.
+func (s *Scheduler) publishDue(ctx context.Context, now time.Time) {
+	scheduled, _, err := s.missions.List(store.MissionFilter{Statuses: []string{models.StatusScheduled}})
+	if err != nil {
+		log.Printf("Scheduler failed to list scheduled missions: %v", err)
+		return
+	}
+	for _, mission := range scheduled {
+		// Shutting down: the rest are handled after the restart
+		if ctx.Err() != nil {
+			return
+		}
+		if mission.ExecuteAt != nil && mission.ExecuteAt.After(now) {
+			continue
+		}
+		queued := models.StatusUpdate{MissionID: mission.MissionID, Status: models.StatusQueued}
+		if err := rabbitmq.SaveMissionStatus(s.missions, queued, models.SourceCommander); err != nil {
+			continue // cancelled meanwhile
+		}
+		mission.Status = models.StatusQueued
+		if err := s.publish(ctx, mission); err != nil {
+			log.Printf("Scheduler failed to publish mission %v: %v", mission.MissionID, err)
+			rabbitmq.FailUnpublished(s.missions, mission.MissionID, err)
+			continue
+		}
+		log.Printf("Scheduled mission %v has been published", mission.MissionID)
+	}
+}
+
+// retryDue starts the next attempt of every RETRY_PENDING mission whose
+// backoff has passed. Missions that cannot be republished are FAILED.
+func (s *Scheduler) retryDue(ctx context.Context, now time.Time) {
+	pending, _, err := s.missions.List(store.MissionFilter{Statuses: []string{models.StatusRetryPending}})
+	if err != nil {
+		log.Printf("Scheduler failed to list missions pending retry: %v", err)
+		return
+	}
+	for _, mission := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		if mission.RetryAt != nil && mission.RetryAt.After(now) {
+			continue
+		}
+		requeued, err := rabbitmq.RequeueMission(s.missions, mission.MissionID, models.SourceCommander)
+		if err != nil {
+			continue // cancelled meanwhile
+		}
+		if err := s.publish(ctx, requeued); err != nil {
+			log.Printf("Scheduler failed to republish mission %v: %v", mission.MissionID, err)
+			rabbitmq.FailUnpublished(s.missions, mission.MissionID, err)
+			continue
+		}
+		log.Printf("Mission %v retried as attempt %d", mission.MissionID, requeued.Attempt)
+	}
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+func TestScheduler_PublishesDueMissions(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Second), now.Add(time.Hour)
+
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "due", Status: models.StatusScheduled, ExecuteAt: &past})
+	missions.Put(&models.Mission{MissionID: "later", Status: models.StatusScheduled, ExecuteAt: &future})
+	missions.Put(&models.Mission{MissionID: "cancelled", Status: models.StatusCancelled, ExecuteAt: &past})
+
+	var published []string
+	s := &Scheduler{
+		missions: missions,
+		publish: func(_ context.Context, m *models.Mission) error {
+			published = append(published, m.MissionID)
+			return nil
+		},
+	}
+	s.publishDue(context.Background(), now)
+
+	if len(published) != 1 || published[0] != "due" {
+		t.Fatalf("expected only the due mission to be published, got %v", published)
+	}
+	if got, _ := missions.Get("due"); got.Status != models.StatusQueued {
+		t.Fatalf("expected due mission to be QUEUED, got %s", got.Status)
+	}
+	if got, _ := missions.Get("later"); got.Status != models.StatusScheduled {
+		t.Fatalf("expected later mission to stay SCHEDULED, got %s", got.Status)
+	}
+}
+
+func TestScheduler_PublishFailureMarksMissionFailed(t *testing.T) {
+	past := time.Now().Add(-time.Second)
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "due", Status: models.StatusScheduled, ExecuteAt: &past})
+
+	s := &Scheduler{
+		missions: missions,
+		publish:  func(context.Context, *models.Mission) error { return errors.New("broker down") },
+	}
+	s.publishDue(context.Background(), time.Now())
+
+	got, _ := missions.Get("due")
+	if got.Status != models.StatusFailed || got.Error == nil || got.Error.Code != models.ErrorCodePublishFailed || got.Error.Message != "broker down" {
+		t.Fatalf("expected FAILED with the publish error, got %s %+v", got.Status, got.Error)
+	}
+}
+
+func TestScheduler_RetriesDueMissions(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Second), now.Add(time.Hour)
+
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "due", Status: models.StatusRetryPending, Attempt: 1, MaxAttempts: 3, RetryAt: &past})
+	missions.Put(&models.Mission{MissionID: "later", Status: models.StatusRetryPending, Attempt: 1, MaxAttempts: 3, RetryAt: &future})
+
+	var published []*models.Mission
+	s := &Scheduler{
+		missions: missions,
+		publish: func(_ context.Context, m *models.Mission) error {
+			published = append(published, m)
+			return nil
+		},
+	}
+	s.retryDue(context.Background(), now)
+
+	if len(published) != 1 || published[0].MissionID != "due" || published[0].Attempt != 2 {
+		t.Fatalf("expected the due mission to be published as attempt 2, got %+v", published)
+	}
+	if got, _ := missions.Get("due"); got.Status != models.StatusQueued {
+		t.Fatalf("expected due mission to be QUEUED, got %s", got.Status)
+	}
+	if got, _ := missions.Get("later"); got.Status != models.StatusRetryPending {
+		t.Fatalf("expected later mission to stay RETRY_PENDING, got %s", got.Status)
+	}
+}
+
+func TestScheduler_LeavesDueMissionsWhenShuttingDown(t *testing.T) {
+	past := time.Now().Add(-time.Second)
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "due", Status: models.StatusScheduled, ExecuteAt: &past})
+
+	s := &Scheduler{
+		missions: missions,
+		publish:  func(context.Context, *models.Mission) error { return errors.New("unexpected publish") },
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.publishDue(ctx, time.Now())
+
+	if got, _ := missions.Get("due"); got.Status != models.StatusScheduled {
+		t.Fatalf("expected the mission to stay SCHEDULED for the next run, got %s", got.Status)
+	}
+}
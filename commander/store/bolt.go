@@ -0,0 +1,359 @@
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	"mission_control/commander/models"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	missionsBucket    = []byte("missions")
+	eventsBucket      = []byte("events") // holds one sub-bucket of events per mission
+	keysBucket        = []byte("idempotency_keys")
+	deliveriesBucket  = []byte("webhook_deliveries") // holds one sub-bucket of delivery attempts per mission
+	deadLettersBucket = []byte("dead_letters")       // keyed by big-endian dead letter ID
+)
+
+// BoltStore persists missions in an embedded bbolt database file,
+// so missions survive commander restarts.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+// NewBoltStore opens (or creates) the database at path
+func NewBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{missionsBucket, eventsBucket, keysBucket, deliveriesBucket, deadLettersBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &BoltStore{db: db}, nil
+}
+
+// Get loads a mission by ID
+func (s *BoltStore) Get(missionID string) (*models.Mission, error) {
+	var mission *models.Mission
+	err := s.db.View(func(tx *bolt.Tx) error {
+		m, err := getMission(tx, missionID)
+		mission = m
+		return err
+	})
+	return mission, err
+}
+
+// Put writes the mission, replacing any previous version
+func (s *BoltStore) Put(mission *models.Mission) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putMission(tx, mission)
+	})
+}
+
+// Update applies fn to the mission inside a single write transaction
+func (s *BoltStore) Update(missionID string, fn func(mission *models.Mission) error) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		mission, err := getMission(tx, missionID)
+		if err != nil {
+			return err
+		}
+		if err := fn(mission); err != nil {
+			return err
+		}
+		return putMission(tx, mission)
+	})
+}
+
+// List returns the persisted missions matching the filter
+func (s *BoltStore) List(filter MissionFilter) ([]*models.Mission, string, error) {
+	var missions []*models.Mission
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(missionsBucket).ForEach(func(k, v []byte) error {
+			var m models.Mission
+			if err := json.Unmarshal(v, &m); err != nil {
+				return err
+			}
+			missions = append(missions, &m)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return applyFilter(missions, filter)
+}
+
+// AddEvent appends the event to the mission's sub-bucket, keyed by sequence
+func (s *BoltStore) AddEvent(event models.MissionEvent) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return appendJSON(tx.Bucket(eventsBucket), event.MissionID, event)
+	})
+}
+
+// Events returns the mission history in insertion order
+func (s *BoltStore) Events(missionID string) ([]models.MissionEvent, error) {
+	var events []models.MissionEvent
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return forEachJSON(tx.Bucket(eventsBucket), missionID, func(e models.MissionEvent) {
+			events = append(events, e)
+		})
+	})
+	return events, err
+}
+
+// AddDelivery appends the attempt to the mission's sub-bucket, keyed by sequence
+func (s *BoltStore) AddDelivery(delivery models.WebhookDelivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return appendJSON(tx.Bucket(deliveriesBucket), delivery.MissionID, delivery)
+	})
+}
+
+// Deliveries returns the mission's delivery attempts in insertion order
+func (s *BoltStore) Deliveries(missionID string) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return forEachJSON(tx.Bucket(deliveriesBucket), missionID, func(d models.WebhookDelivery) {
+			deliveries = append(deliveries, d)
+		})
+	})
+	return deliveries, err
+}
+
+// appendJSON stores v in the mission's sub-bucket of b under the next sequence number
+func appendJSON(b *bolt.Bucket, missionID string, v any) error {
+	mb, err := b.CreateBucketIfNotExists([]byte(missionID))
+	if err != nil {
+		return err
+	}
+	seq, err := mb.NextSequence()
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return mb.Put(sequenceKey(seq), body)
+}
+
+// sequenceKey encodes a sequence number so keys sort in sequence order
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// forEachJSON decodes every value of the mission's sub-bucket of b in sequence order
+func forEachJSON[T any](b *bolt.Bucket, missionID string, fn func(T)) error {
+	mb := b.Bucket([]byte(missionID))
+	if mb == nil {
+		return nil
+	}
+	return mb.ForEach(func(k, v []byte) error {
+		var item T
+		if err := json.Unmarshal(v, &item); err != nil {
+			return err
+		}
+		fn(item)
+		return nil
+	})
+}
+
+// AddDeadLetter stores the message under the bucket's next sequence number
+func (s *BoltStore) AddDeadLetter(letter *models.DeadLetter) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deadLettersBucket)
+		id, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		letter.ID = id
+		body, err := json.Marshal(letter)
+		if err != nil {
+			return err
+		}
+		return b.Put(sequenceKey(id), body)
+	})
+}
+
+// DeadLetters returns the messages of queue, or of every queue, in ID order
+func (s *BoltStore) DeadLetters(queue string) ([]models.DeadLetter, error) {
+	var letters []models.DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deadLettersBucket).ForEach(func(k, v []byte) error {
+			var letter models.DeadLetter
+			if err := json.Unmarshal(v, &letter); err != nil {
+				return err
+			}
+			if queue == "" || letter.Queue == queue {
+				letters = append(letters, letter)
+			}
+			return nil
+		})
+	})
+	return letters, err
+}
+
+// DeadLetter loads the message with the given ID
+func (s *BoltStore) DeadLetter(id uint64) (*models.DeadLetter, error) {
+	var letter *models.DeadLetter
+	err := s.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(deadLettersBucket).Get(sequenceKey(id))
+		if v == nil {
+			return ErrDeadLetterNotFound
+		}
+		letter = &models.DeadLetter{}
+		return json.Unmarshal(v, letter)
+	})
+	return letter, err
+}
+
+// DeleteDeadLetter deletes the message with the given ID
+func (s *BoltStore) DeleteDeadLetter(id uint64) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deadLettersBucket)
+		if b.Get(sequenceKey(id)) == nil {
+			return ErrDeadLetterNotFound
+		}
+		return b.Delete(sequenceKey(id))
+	})
+}
+
+// PurgeDeadLetters deletes the messages of queue, or of every queue
+func (s *BoltStore) PurgeDeadLetters(queue string) (int, error) {
+	var purged [][]byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(deadLettersBucket)
+		// Collect first: deleting while iterating can skip keys
+		err := b.ForEach(func(k, v []byte) error {
+			var letter models.DeadLetter
+			if err := json.Unmarshal(v, &letter); err != nil {
+				return err
+			}
+			if queue == "" || letter.Queue == queue {
+				purged = append(purged, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range purged {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(purged), nil
+}
+
+// Reserve claims the key unless an unexpired record already holds it
+func (s *BoltStore) Reserve(record IdempotencyRecord) (*IdempotencyRecord, error) {
+	var existing *IdempotencyRecord
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		if v := b.Get([]byte(record.Key)); v != nil {
+			var r IdempotencyRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if time.Now().Before(r.ExpiresAt) {
+				existing = &r
+				return nil
+			}
+		}
+		return putJSON(b, record.Key, record)
+	})
+	return existing, err
+}
+
+// Complete stores the final response for the key
+func (s *BoltStore) Complete(record IdempotencyRecord) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(keysBucket), record.Key, record)
+	})
+}
+
+// Release forgets the key
+func (s *BoltStore) Release(key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(keysBucket).Delete([]byte(key))
+	})
+}
+
+// PurgeExpiredKeys deletes keys that expired before now
+func (s *BoltStore) PurgeExpiredKeys(now time.Time) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(keysBucket)
+		// Collect first: deleting while iterating can skip keys
+		var expired [][]byte
+		err := b.ForEach(func(k, v []byte) error {
+			var r IdempotencyRecord
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			if !now.Before(r.ExpiresAt) {
+				expired = append(expired, k)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, k := range expired {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Close closes the underlying database file
+func (s *BoltStore) Close() error {
+	return s.db.Close()
+}
+
+// getMission decodes a mission from the missions bucket
+func getMission(tx *bolt.Tx, missionID string) (*models.Mission, error) {
+	v := tx.Bucket(missionsBucket).Get([]byte(missionID))
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	var m models.Mission
+	if err := json.Unmarshal(v, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// putMission encodes a mission into the missions bucket
+func putMission(tx *bolt.Tx, mission *models.Mission) error {
+	return putJSON(tx.Bucket(missionsBucket), mission.MissionID, mission)
+}
+
+// putJSON stores v as JSON under key
+func putJSON(b *bolt.Bucket, key string, v any) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), body)
+}
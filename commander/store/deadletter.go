@@ -0,0 +1,26 @@
+package store
+
+import (
+	"errors"
+
+	"mission_control/commander/models"
+)
+
+// ErrDeadLetterNotFound is returned when a dead letter does not exist in the store
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetterStore keeps dead-lettered messages until they are replayed or purged
+type DeadLetterStore interface {
+	// AddDeadLetter stores the message under a new ID, which is set on it
+	AddDeadLetter(letter *models.DeadLetter) error
+	// DeadLetters returns the messages dead-lettered from queue, or from every
+	// queue if it is empty, oldest first
+	DeadLetters(queue string) ([]models.DeadLetter, error)
+	// DeadLetter returns the message with the given ID or ErrDeadLetterNotFound
+	DeadLetter(id uint64) (*models.DeadLetter, error)
+	// DeleteDeadLetter forgets the message with the given ID or returns ErrDeadLetterNotFound
+	DeleteDeadLetter(id uint64) error
+	// PurgeDeadLetters deletes the messages of queue, or of every queue if it
+	// is empty, and returns how many were deleted
+	PurgeDeadLetters(queue string) (int, error)
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"mission_control/commander/models"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// MissionFilter selects and paginates missions returned by List.
+// Zero values disable the corresponding filter.
+type MissionFilter struct {
+	Statuses      []string  // Match any of these statuses
+	Priorities    []uint8   // Match any of these priorities
+	CreatedAfter  time.Time // Only missions created strictly after this time
+	CreatedBefore time.Time // Only missions created strictly before this time
+	OrderContains string    // Case-insensitive substring of the order
+	Cursor        string    // Opaque cursor returned by a previous page
+	Limit         int       // Page size, 0 means no limit
+}
+
+// Match reports whether the mission satisfies the filter (ignoring pagination)
+func (f MissionFilter) Match(m *models.Mission) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, m.Status) {
+		return false
+	}
+	if len(f.Priorities) > 0 && !slices.Contains(f.Priorities, m.Priority) {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && !m.CreatedAt.After(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !m.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if f.OrderContains != "" && !strings.Contains(strings.ToLower(m.Order), strings.ToLower(f.OrderContains)) {
+		return false
+	}
+	return true
+}
+
+// applyFilter sorts missions newest first (mission ID breaks ties, so the
+// order is stable), drops everything up to the cursor and returns one page
+// plus the cursor of the next page, if any.
+func applyFilter(missions []*models.Mission, f MissionFilter) ([]*models.Mission, string, error) {
+	matched := make([]*models.Mission, 0, len(missions))
+	for _, m := range missions {
+		if f.Match(m) {
+			matched = append(matched, m)
+		}
+	}
+	slices.SortFunc(matched, compareMissions)
+
+	if f.Cursor != "" {
+		after, err := decodeCursor(f.Cursor)
+		if err != nil {
+			return nil, "", err
+		}
+		i, _ := slices.BinarySearchFunc(matched, after, func(m *models.Mission, c *models.Mission) int {
+			if compareMissions(m, c) <= 0 {
+				return -1
+			}
+			return 1
+		})
+		matched = matched[i:]
+	}
+
+	if f.Limit > 0 && len(matched) > f.Limit {
+		return matched[:f.Limit], encodeCursor(matched[f.Limit-1]), nil
+	}
+	return matched, "", nil
+}
+
+// compareMissions orders missions by creation time descending, then ID descending
+func compareMissions(a, b *models.Mission) int {
+	if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+		return c
+	}
+	return strings.Compare(b.MissionID, a.MissionID)
+}
+
+// encodeCursor encodes the sort key of the last mission on a page
+func encodeCursor(m *models.Mission) string {
+	key := fmt.Sprintf("%d|%s", m.CreatedAt.UnixNano(), m.MissionID)
+	return base64.RawURLEncoding.EncodeToString([]byte(key))
+}
+
+// decodeCursor returns a mission holding only the sort key encoded in the cursor
+func decodeCursor(cursor string) (*models.Mission, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	nanos, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return nil, ErrInvalidCursor
+	}
+	n, err := strconv.ParseInt(nanos, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &models.Mission{MissionID: id, CreatedAt: time.Unix(0, n)}, nil
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// IdempotencyRecord remembers the outcome of a request sent with an Idempotency-Key
+type IdempotencyRecord struct {
+	Key         string          `json:"key"`
+	RequestHash string          `json:"request_hash"` // Hash of the request body the key was first used with
+	MissionID   string          `json:"mission_id"`
+	StatusCode  int             `json:"status_code"` // Zero while the first request is still in flight
+	Response    json.RawMessage `json:"response,omitempty"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+}
+
+// Completed reports whether the first request has finished and its response can be replayed
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// IdempotencyStore keeps idempotency keys for a limited window
+type IdempotencyStore interface {
+	// Reserve claims record.Key for a new request. If the key is already
+	// claimed and not expired, the existing record is returned instead and
+	// nothing is stored.
+	Reserve(record IdempotencyRecord) (*IdempotencyRecord, error)
+	// Complete stores the final response of a reserved key
+	Complete(record IdempotencyRecord) error
+	// Release forgets a reserved key so the request can be retried
+	Release(key string) error
+	// PurgeExpiredKeys deletes every key that expired before now
+	PurgeExpiredKeys(now time.Time) error
+}
+
+// PurgeExpiredKeysEvery deletes expired idempotency keys on every tick until ctx is done
+func PurgeExpiredKeysEvery(ctx context.Context, keys IdempotencyStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := keys.PurgeExpiredKeys(now); err != nil {
+				log.Printf("Failed to purge expired idempotency keys: %v", err)
+			}
+		}
+	}
+}
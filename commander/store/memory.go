@@ -0,0 +1,232 @@
+package store
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	"mission_control/commander/models"
+)
+
+// MemoryStore keeps missions in a map guarded by a RWMutex.
+// Missions are lost when the process exits.
+type MemoryStore struct {
+	mu         sync.RWMutex
+	missions   map[string]*models.Mission
+	events     map[string][]models.MissionEvent
+	keys       map[string]IdempotencyRecord
+	deliveries map[string][]models.WebhookDelivery
+
+	deadLetters      []models.DeadLetter // oldest first
+	lastDeadLetterID uint64
+}
+
+// NewMemoryStore returns an empty in-memory mission store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		missions:   make(map[string]*models.Mission),
+		events:     make(map[string][]models.MissionEvent),
+		keys:       make(map[string]IdempotencyRecord),
+		deliveries: make(map[string][]models.WebhookDelivery),
+	}
+}
+
+// Get returns a copy of the mission so callers cannot mutate shared state
+func (s *MemoryStore) Get(missionID string) (*models.Mission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	mission, ok := s.missions[missionID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	m := *mission
+	return &m, nil
+}
+
+// Put stores a copy of the mission
+func (s *MemoryStore) Put(mission *models.Mission) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := *mission
+	s.missions[m.MissionID] = &m
+	return nil
+}
+
+// Update applies fn to a copy of the mission and stores it if fn succeeds
+func (s *MemoryStore) Update(missionID string, fn func(mission *models.Mission) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mission, ok := s.missions[missionID]
+	if !ok {
+		return ErrNotFound
+	}
+	m := *mission
+	if err := fn(&m); err != nil {
+		return err
+	}
+	s.missions[missionID] = &m
+	return nil
+}
+
+// List returns copies of the missions matching the filter
+func (s *MemoryStore) List(filter MissionFilter) ([]*models.Mission, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	missions := make([]*models.Mission, 0, len(s.missions))
+	for _, mission := range s.missions {
+		m := *mission
+		missions = append(missions, &m)
+	}
+	return applyFilter(missions, filter)
+}
+
+// AddEvent appends the event to the mission history
+func (s *MemoryStore) AddEvent(event models.MissionEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event.Mission = nil
+	s.events[event.MissionID] = append(s.events[event.MissionID], event)
+	return nil
+}
+
+// Events returns a copy of the mission history
+func (s *MemoryStore) Events(missionID string) ([]models.MissionEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.MissionEvent(nil), s.events[missionID]...), nil
+}
+
+// AddDelivery appends the attempt to the mission's delivery log
+func (s *MemoryStore) AddDelivery(delivery models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deliveries[delivery.MissionID] = append(s.deliveries[delivery.MissionID], delivery)
+	return nil
+}
+
+// Deliveries returns a copy of the mission's delivery log
+func (s *MemoryStore) Deliveries(missionID string) ([]models.WebhookDelivery, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.WebhookDelivery(nil), s.deliveries[missionID]...), nil
+}
+
+// AddDeadLetter appends the message under the next ID
+func (s *MemoryStore) AddDeadLetter(letter *models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastDeadLetterID++
+	letter.ID = s.lastDeadLetterID
+	s.deadLetters = append(s.deadLetters, *letter)
+	return nil
+}
+
+// DeadLetters returns copies of the messages of queue, or of every queue
+func (s *MemoryStore) DeadLetters(queue string) ([]models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var letters []models.DeadLetter
+	for _, letter := range s.deadLetters {
+		if queue == "" || letter.Queue == queue {
+			letters = append(letters, letter)
+		}
+	}
+	return letters, nil
+}
+
+// DeadLetter returns a copy of the message with the given ID
+func (s *MemoryStore) DeadLetter(id uint64) (*models.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, letter := range s.deadLetters {
+		if letter.ID == id {
+			return &letter, nil
+		}
+	}
+	return nil, ErrDeadLetterNotFound
+}
+
+// DeleteDeadLetter removes the message with the given ID
+func (s *MemoryStore) DeleteDeadLetter(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, letter := range s.deadLetters {
+		if letter.ID == id {
+			s.deadLetters = slices.Delete(s.deadLetters, i, i+1)
+			return nil
+		}
+	}
+	return ErrDeadLetterNotFound
+}
+
+// PurgeDeadLetters removes the messages of queue, or of every queue
+func (s *MemoryStore) PurgeDeadLetters(queue string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	before := len(s.deadLetters)
+	s.deadLetters = slices.DeleteFunc(s.deadLetters, func(letter models.DeadLetter) bool {
+		return queue == "" || letter.Queue == queue
+	})
+	return before - len(s.deadLetters), nil
+}
+
+// Reserve claims the key unless an unexpired record already holds it
+func (s *MemoryStore) Reserve(record IdempotencyRecord) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.keys[record.Key]; ok && time.Now().Before(existing.ExpiresAt) {
+		return &existing, nil
+	}
+	s.keys[record.Key] = record
+	return nil, nil
+}
+
+// Complete stores the final response for the key
+func (s *MemoryStore) Complete(record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys[record.Key] = record
+	return nil
+}
+
+// Release forgets the key
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.keys, key)
+	return nil
+}
+
+// PurgeExpiredKeys deletes keys that expired before now
+func (s *MemoryStore) PurgeExpiredKeys(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, record := range s.keys {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.keys, key)
+		}
+	}
+	return nil
+}
+
+// Close is a no-op for the in-memory store
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -1,10 +1,61 @@
 package store
 
-import "sync"
-import "mission_control/commander/models"
+import (
+	"errors"
+	"fmt"
 
-// Missions map stores all missions in memory
-var MissionsMap = make(map[string]*models.Mission)
+	"mission_control/commander/config"
+	"mission_control/commander/models"
+)
 
-// MissionsMutex protects access to the Missions map
-var MissionsMutex = sync.RWMutex{}
+// ErrNotFound is returned when a mission does not exist in the store
+var ErrNotFound = errors.New("mission not found")
+
+// MissionStore persists missions and their current status
+type MissionStore interface {
+	// Get returns a copy of the mission with the given ID or ErrNotFound
+	Get(missionID string) (*models.Mission, error)
+	// Put inserts or replaces a mission
+	Put(mission *models.Mission) error
+	// Update atomically applies fn to the stored mission and saves the result.
+	// It returns ErrNotFound for unknown missions and fn's error unchanged,
+	// in which case nothing is saved.
+	Update(missionID string, fn func(mission *models.Mission) error) error
+	// List returns copies of the missions matching the filter, newest first,
+	// and the cursor of the next page ("" when there are no more missions)
+	List(filter MissionFilter) ([]*models.Mission, string, error)
+	// AddEvent appends a status transition to the mission's history
+	AddEvent(event models.MissionEvent) error
+	// Events returns the mission's status history in the order it was recorded
+	Events(missionID string) ([]models.MissionEvent, error)
+	// Close releases any resources held by the store
+	Close() error
+}
+
+// DeliveryStore records webhook delivery attempts per mission
+type DeliveryStore interface {
+	// AddDelivery appends a delivery attempt to the mission's delivery log
+	AddDelivery(delivery models.WebhookDelivery) error
+	// Deliveries returns the mission's delivery attempts in the order they were recorded
+	Deliveries(missionID string) ([]models.WebhookDelivery, error)
+}
+
+// Store is implemented by every backend selectable through config
+type Store interface {
+	MissionStore
+	IdempotencyStore
+	DeliveryStore
+	DeadLetterStore
+}
+
+// New creates the store selected by the MISSION_STORE config
+func New() (Store, error) {
+	switch backend := config.GetMissionStoreBackend(); backend {
+	case config.STORE_MEMORY:
+		return NewMemoryStore(), nil
+	case config.STORE_BOLT:
+		return NewBoltStore(config.GetMissionStorePath())
+	default:
+		return nil, fmt.Errorf("unknown mission store backend %q", backend)
+	}
+}
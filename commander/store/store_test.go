@@ -0,0 +1,370 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"mission_control/commander/models"
+)
+
+// stores returns one instance of every Store implementation
+func stores(t *testing.T) map[string]Store {
+	bolt, err := NewBoltStore(filepath.Join(t.TempDir(), "missions.db"))
+	if err != nil {
+		t.Fatalf("failed to open bolt store: %v", err)
+	}
+	t.Cleanup(func() { bolt.Close() })
+
+	return map[string]Store{
+		"memory": NewMemoryStore(),
+		"bolt":   bolt,
+	}
+}
+
+func TestMissionStore_PutAndGet(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			mission := &models.Mission{MissionID: "m1", Order: "Recon", Status: "QUEUED"}
+			if err := s.Put(mission); err != nil {
+				t.Fatalf("put failed: %v", err)
+			}
+
+			// Mutating the caller's copy must not change the stored mission
+			mission.Status = "CHANGED"
+
+			got, err := s.Get("m1")
+			if err != nil {
+				t.Fatalf("get failed: %v", err)
+			}
+			if got.Order != "Recon" || got.Status != "QUEUED" {
+				t.Fatalf("unexpected mission: %+v", got)
+			}
+		})
+	}
+}
+
+func TestMissionStore_GetNotFound(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.Get("unknown"); err != ErrNotFound {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMissionStore_Update(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s.Put(&models.Mission{MissionID: "m1", Order: "Recon", Status: "QUEUED"})
+
+			err := s.Update("m1", func(m *models.Mission) error {
+				m.Status = "IN_PROGRESS"
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("update failed: %v", err)
+			}
+
+			// A failing update must not be saved
+			rejected := errors.New("rejected")
+			err = s.Update("m1", func(m *models.Mission) error {
+				m.Status = "QUEUED"
+				return rejected
+			})
+			if err != rejected {
+				t.Fatalf("expected fn error, got %v", err)
+			}
+			if got, _ := s.Get("m1"); got.Status != "IN_PROGRESS" {
+				t.Fatalf("expected IN_PROGRESS, got %s", got.Status)
+			}
+
+			if err := s.Update("unknown", func(*models.Mission) error { return nil }); err != ErrNotFound {
+				t.Fatalf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestMissionStore_Events(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, status := range []string{"QUEUED", "IN_PROGRESS", "COMPLETED"} {
+				event := models.MissionEvent{MissionID: "m1", Status: status, Source: models.SourceSoldier}
+				if err := s.AddEvent(event); err != nil {
+					t.Fatalf("add event failed: %v", err)
+				}
+			}
+			s.AddEvent(models.MissionEvent{MissionID: "m2", Status: "QUEUED"})
+
+			events, err := s.Events("m1")
+			if err != nil {
+				t.Fatalf("events failed: %v", err)
+			}
+			if len(events) != 3 || events[0].Status != "QUEUED" || events[2].Status != "COMPLETED" {
+				t.Fatalf("unexpected events: %+v", events)
+			}
+			if events, _ := s.Events("unknown"); len(events) != 0 {
+				t.Fatalf("expected no events, got %+v", events)
+			}
+		})
+	}
+}
+
+func TestDeliveryStore_Deliveries(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			for attempt := 1; attempt <= 2; attempt++ {
+				delivery := models.WebhookDelivery{MissionID: "m1", EventID: 7, Attempt: attempt, Delivered: attempt == 2}
+				if err := s.AddDelivery(delivery); err != nil {
+					t.Fatalf("add delivery failed: %v", err)
+				}
+			}
+
+			deliveries, err := s.Deliveries("m1")
+			if err != nil {
+				t.Fatalf("deliveries failed: %v", err)
+			}
+			if len(deliveries) != 2 || deliveries[0].Attempt != 1 || !deliveries[1].Delivered {
+				t.Fatalf("unexpected deliveries: %+v", deliveries)
+			}
+			if deliveries, _ := s.Deliveries("unknown"); len(deliveries) != 0 {
+				t.Fatalf("expected no deliveries, got %+v", deliveries)
+			}
+		})
+	}
+}
+
+func TestDeadLetterStore(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, queue := range []string{"status_queue", "orders_queue", "status_queue"} {
+				letter := &models.DeadLetter{Queue: queue, Reason: "rejected", Body: "{not json"}
+				if err := s.AddDeadLetter(letter); err != nil || letter.ID == 0 {
+					t.Fatalf("add dead letter failed: %v (id %d)", err, letter.ID)
+				}
+			}
+
+			status, _ := s.DeadLetters("status_queue")
+			if len(status) != 2 || status[0].ID != 1 || status[1].ID != 3 {
+				t.Fatalf("expected dead letters 1 and 3, got %+v", status)
+			}
+			if letter, err := s.DeadLetter(2); err != nil || letter.Queue != "orders_queue" || letter.Body != "{not json" {
+				t.Fatalf("unexpected dead letter 2: %+v %v", letter, err)
+			}
+
+			if err := s.DeleteDeadLetter(2); err != nil {
+				t.Fatalf("delete failed: %v", err)
+			}
+			if _, err := s.DeadLetter(2); err != ErrDeadLetterNotFound {
+				t.Fatalf("expected ErrDeadLetterNotFound, got %v", err)
+			}
+			if err := s.DeleteDeadLetter(2); err != ErrDeadLetterNotFound {
+				t.Fatalf("expected ErrDeadLetterNotFound for a second delete, got %v", err)
+			}
+
+			if purged, err := s.PurgeDeadLetters("status_queue"); err != nil || purged != 2 {
+				t.Fatalf("expected 2 purged, got %d %v", purged, err)
+			}
+			if all, _ := s.DeadLetters(""); len(all) != 0 {
+				t.Fatalf("expected no dead letters left, got %+v", all)
+			}
+		})
+	}
+}
+
+func TestMissionStore_ListFilterAndPaginate(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			// m0..m4 created one minute apart; m3 and m4 share a timestamp
+			for i, status := range []string{"QUEUED", "FAILED", "QUEUED", "COMPLETED", "QUEUED"} {
+				created := base.Add(time.Duration(min(i, 3)) * time.Minute)
+				s.Put(&models.Mission{
+					MissionID: fmt.Sprintf("m%d", i),
+					Order:     fmt.Sprintf("Attack Zone-%d", i),
+					Status:    status,
+					Priority:  uint8(i % 2 * 9),
+					CreatedAt: created,
+				})
+			}
+
+			all, next, err := s.List(MissionFilter{})
+			if err != nil || next != "" {
+				t.Fatalf("unexpected list result: next=%q err=%v", next, err)
+			}
+			if ids := missionIDs(all); ids != "m4,m3,m2,m1,m0" {
+				t.Fatalf("expected newest first with stable ties, got %s", ids)
+			}
+
+			queued, _, _ := s.List(MissionFilter{Statuses: []string{"QUEUED"}})
+			if ids := missionIDs(queued); ids != "m4,m2,m0" {
+				t.Fatalf("unexpected status filter result %s", ids)
+			}
+
+			window, _, _ := s.List(MissionFilter{CreatedAfter: base, CreatedBefore: base.Add(3 * time.Minute)})
+			if ids := missionIDs(window); ids != "m2,m1" {
+				t.Fatalf("unexpected created window result %s", ids)
+			}
+
+			urgent, _, _ := s.List(MissionFilter{Priorities: []uint8{9}})
+			if ids := missionIDs(urgent); ids != "m3,m1" {
+				t.Fatalf("unexpected priority filter result %s", ids)
+			}
+
+			byOrder, _, _ := s.List(MissionFilter{OrderContains: "zone-1"})
+			if ids := missionIDs(byOrder); ids != "m1" {
+				t.Fatalf("unexpected order filter result %s", ids)
+			}
+
+			// Walk all pages of size 2
+			var pages []string
+			filter := MissionFilter{Limit: 2}
+			for {
+				page, next, err := s.List(filter)
+				if err != nil {
+					t.Fatalf("list failed: %v", err)
+				}
+				pages = append(pages, missionIDs(page))
+				if next == "" {
+					break
+				}
+				filter.Cursor = next
+			}
+			if got := strings.Join(pages, " "); got != "m4,m3 m2,m1 m0" {
+				t.Fatalf("unexpected pages %q", got)
+			}
+
+			if _, _, err := s.List(MissionFilter{Cursor: "not-a-cursor"}); err != ErrInvalidCursor {
+				t.Fatalf("expected ErrInvalidCursor, got %v", err)
+			}
+		})
+	}
+}
+
+// missionIDs joins mission IDs for compact assertions
+func missionIDs(missions []*models.Mission) string {
+	ids := make([]string, len(missions))
+	for i, m := range missions {
+		ids[i] = m.MissionID
+	}
+	return strings.Join(ids, ",")
+}
+
+func TestIdempotencyStore_ReserveCompleteRelease(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			record := IdempotencyRecord{
+				Key:         "k1",
+				RequestHash: "h1",
+				MissionID:   "m1",
+				ExpiresAt:   time.Now().Add(time.Hour),
+			}
+			if existing, err := s.Reserve(record); err != nil || existing != nil {
+				t.Fatalf("expected fresh reservation, got %+v, %v", existing, err)
+			}
+
+			// A second reservation sees the in-flight record
+			existing, _ := s.Reserve(IdempotencyRecord{Key: "k1", MissionID: "m2", ExpiresAt: time.Now().Add(time.Hour)})
+			if existing == nil || existing.MissionID != "m1" || existing.Completed() {
+				t.Fatalf("expected pending record for m1, got %+v", existing)
+			}
+
+			record.StatusCode = 202
+			record.Response = []byte(`{"mission_id":"m1"}`)
+			s.Complete(record)
+			existing, _ = s.Reserve(IdempotencyRecord{Key: "k1", ExpiresAt: time.Now().Add(time.Hour)})
+			if existing == nil || !existing.Completed() || string(existing.Response) != `{"mission_id":"m1"}` {
+				t.Fatalf("expected completed record, got %+v", existing)
+			}
+
+			// Released keys can be reserved again
+			s.Release("k1")
+			if existing, _ := s.Reserve(record); existing != nil {
+				t.Fatalf("expected key to be free after release, got %+v", existing)
+			}
+		})
+	}
+}
+
+func TestIdempotencyStore_Expiry(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s.Reserve(IdempotencyRecord{Key: "old", MissionID: "m1", ExpiresAt: time.Now().Add(-time.Second)})
+			s.Reserve(IdempotencyRecord{Key: "new", MissionID: "m2", ExpiresAt: time.Now().Add(time.Hour)})
+
+			// Expired keys can be reused right away
+			if existing, _ := s.Reserve(IdempotencyRecord{Key: "old", MissionID: "m3", ExpiresAt: time.Now().Add(-time.Second)}); existing != nil {
+				t.Fatalf("expected expired key to be reusable, got %+v", existing)
+			}
+
+			if err := s.PurgeExpiredKeys(time.Now()); err != nil {
+				t.Fatalf("purge failed: %v", err)
+			}
+			if existing, _ := s.Reserve(IdempotencyRecord{Key: "new", ExpiresAt: time.Now().Add(time.Hour)}); existing == nil {
+				t.Fatalf("unexpired key must survive the purge")
+			}
+		})
+	}
+}
+
+func TestPurgeExpiredKeysEvery_StopsWithContext(t *testing.T) {
+	keys := NewMemoryStore()
+	keys.Reserve(IdempotencyRecord{Key: "old", MissionID: "m1", ExpiresAt: time.Now().Add(-time.Second)})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		PurgeExpiredKeysEvery(ctx, keys, time.Millisecond)
+		close(stopped)
+	}()
+	// Wait for a tick to purge the expired key
+	for deadline := time.Now().Add(time.Second); ; time.Sleep(time.Millisecond) {
+		keys.mu.RLock()
+		_, ok := keys.keys["old"]
+		keys.mu.RUnlock()
+		if !ok {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the expired key to be purged")
+		}
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("expected the purge loop to stop once ctx is done")
+	}
+}
+
+func TestBoltStore_SurvivesReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missions.db")
+
+	s, err := NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("failed to open bolt store: %v", err)
+	}
+	s.Put(&models.Mission{MissionID: "m1", Order: "Recon", Status: "QUEUED"})
+	s.Close()
+
+	s, err = NewBoltStore(path)
+	if err != nil {
+		t.Fatalf("failed to reopen bolt store: %v", err)
+	}
+	defer s.Close()
+
+	got, err := s.Get("m1")
+	if err != nil {
+		t.Fatalf("expected mission after reopen, got %v", err)
+	}
+	if got.Order != "Recon" {
+		t.Fatalf("unexpected mission: %+v", got)
+	}
+}
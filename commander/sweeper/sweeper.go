@@ -0,0 +1,124 @@
+package sweeper
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
+	"mission_control/commander/store"
+)
+
+// Sweeper marks IN_PROGRESS missions TIMED_OUT once their deadline has
+// passed, e.g. because the soldier running them died, and optionally
+// republishes them as a new attempt. It also republishes QUEUED missions
+// whose soldier went away before taking them, as their order went with the
+// soldier's own queue.
+type Sweeper struct {
+	missions       store.MissionStore
+	soldiers       *registry.Registry
+	publish        func(ctx context.Context, mission *models.Mission) error
+	interval       time.Duration
+	republishLimit int // How many times a mission may be republished after timing out
+}
+
+// New returns a sweeper that checks deadlines every interval and republishes
+// timed-out missions with publisher up to republishLimit times, routing missions that
+// require capabilities to a soldier from soldiers
+func New(publisher broker.Broker, missions store.MissionStore, soldiers *registry.Registry, interval time.Duration, republishLimit int) *Sweeper {
+	return &Sweeper{
+		missions: missions,
+		soldiers: soldiers,
+		publish: func(ctx context.Context, mission *models.Mission) error {
+			return rabbitmq.PublishMission(ctx, publisher, missions, soldiers, mission)
+		},
+		interval:       interval,
+		republishLimit: republishLimit,
+	}
+}
+
+// Run sweeps overdue missions on every tick until ctx is done
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	log.Println("Mission timeout sweeper started")
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Mission timeout sweeper stopped")
+			return
+		case now := <-ticker.C:
+			s.sweep(ctx, now)
+			s.reassign(ctx, now)
+		}
+	}
+}
+
+// sweep times out every IN_PROGRESS mission whose deadline is before now
+func (s *Sweeper) sweep(ctx context.Context, now time.Time) {
+	inProgress, _, err := s.missions.List(store.MissionFilter{Statuses: []string{models.StatusInProgress}})
+	if err != nil {
+		log.Printf("Sweeper failed to list in-progress missions: %v", err)
+		return
+	}
+	for _, mission := range inProgress {
+		// Shutting down: the rest are handled after the restart
+		if ctx.Err() != nil {
+			return
+		}
+		if mission.Deadline == nil || mission.Deadline.After(now) {
+			continue
+		}
+		timedOut := models.StatusUpdate{MissionID: mission.MissionID, Status: models.StatusTimedOut}
+		if err := rabbitmq.SaveMissionStatus(s.missions, timedOut, models.SourceCommander); err != nil {
+			continue // finished meanwhile
+		}
+		log.Printf("Mission %v timed out after deadline %v", mission.MissionID, mission.Deadline)
+
+		if mission.Attempt > s.republishLimit {
+			continue
+		}
+		requeued, err := rabbitmq.RequeueMission(s.missions, mission.MissionID, models.SourceCommander)
+		if err != nil {
+			continue
+		}
+		if err := s.publish(ctx, requeued); err != nil {
+			log.Printf("Sweeper failed to republish mission %v: %v", mission.MissionID, err)
+			rabbitmq.FailUnpublished(s.missions, mission.MissionID, err)
+		}
+	}
+}
+
+// reassign republishes every QUEUED mission whose order was lost with its
+// soldier's queue. Missions wait while the gone soldier is still the only one
+// that could take them; those that can no longer be routed at all, e.g.
+// targeted at a forgotten soldier, are FAILED.
+func (s *Sweeper) reassign(ctx context.Context, now time.Time) {
+	queued, _, err := s.missions.List(store.MissionFilter{Statuses: []string{models.StatusQueued}})
+	if err != nil {
+		log.Printf("Sweeper failed to list queued missions: %v", err)
+		return
+	}
+	for _, mission := range queued {
+		if ctx.Err() != nil {
+			return
+		}
+		if mission.RoutedAt == nil || !s.soldiers.Lost(mission.Soldier, *mission.RoutedAt, now) {
+			continue
+		}
+		if next, err := s.soldiers.Pick(mission, now); err == nil && next.SoldierID == mission.Soldier && next.State == models.SoldierOffline {
+			continue
+		}
+		log.Printf("Soldier %v of queued mission %v went away — republishing", mission.Soldier, mission.MissionID)
+		mission.Soldier, mission.RoutedAt = "", nil
+		if err := s.publish(ctx, mission); err != nil {
+			log.Printf("Sweeper failed to republish mission %v: %v", mission.MissionID, err)
+			rabbitmq.FailUnpublished(s.missions, mission.MissionID, err)
+		}
+	}
+}
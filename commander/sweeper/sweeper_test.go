@@ -0,0 +1,145 @@
+package sweeper
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/models"
+	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
+	"mission_control/commander/store"
+)
+
+// startMission stores a mission and moves it IN_PROGRESS with the given deadline
+func startMission(missions store.MissionStore, id string, deadline time.Time) {
+	missions.Put(&models.Mission{MissionID: id, Status: models.StatusInProgress, Attempt: 1, Deadline: &deadline})
+}
+
+func TestSweeper_TimesOutOverdueMissions(t *testing.T) {
+	now := time.Now()
+	missions := store.NewMemoryStore()
+	startMission(missions, "overdue", now.Add(-time.Second))
+	startMission(missions, "running", now.Add(time.Hour))
+
+	var published []string
+	s := &Sweeper{
+		missions: missions,
+		publish: func(_ context.Context, m *models.Mission) error {
+			published = append(published, m.MissionID)
+			return nil
+		},
+	}
+	s.sweep(context.Background(), now)
+
+	if got, _ := missions.Get("overdue"); got.Status != models.StatusTimedOut {
+		t.Fatalf("expected overdue mission TIMED_OUT, got %s", got.Status)
+	}
+	if got, _ := missions.Get("running"); got.Status != models.StatusInProgress {
+		t.Fatalf("expected running mission to stay IN_PROGRESS, got %s", got.Status)
+	}
+	if len(published) != 0 {
+		t.Fatalf("republishing is disabled by default, got %v", published)
+	}
+
+	// A late COMPLETED from the dead soldier is recorded but does not resurrect the mission
+	late := models.StatusUpdate{MissionID: "overdue", Status: models.StatusCompleted, Seq: 2, Attempt: 1}
+	if err := rabbitmq.SaveMissionStatus(missions, late, models.SourceSoldier); err == nil {
+		t.Fatal("expected late update to be rejected")
+	}
+	if got, _ := missions.Get("overdue"); got.Status != models.StatusTimedOut {
+		t.Fatalf("expected mission to stay TIMED_OUT, got %s", got.Status)
+	}
+	events, _ := missions.Events("overdue")
+	if last := events[len(events)-1]; !last.Late || last.Status != models.StatusCompleted {
+		t.Fatalf("expected late COMPLETED event, got %+v", last)
+	}
+}
+
+func TestSweeper_RepublishesAsNewAttempt(t *testing.T) {
+	now := time.Now()
+	missions := store.NewMemoryStore()
+	startMission(missions, "overdue", now.Add(-time.Second))
+
+	var published []*models.Mission
+	s := &Sweeper{
+		missions: missions,
+		publish: func(_ context.Context, m *models.Mission) error {
+			published = append(published, m)
+			return nil
+		},
+		republishLimit: 1,
+	}
+	s.sweep(context.Background(), now)
+
+	if len(published) != 1 || published[0].Attempt != 2 {
+		t.Fatalf("expected attempt 2 to be published, got %+v", published)
+	}
+	got, _ := missions.Get("overdue")
+	if got.Status != models.StatusQueued || got.Attempt != 2 || got.Deadline != nil {
+		t.Fatalf("unexpected requeued mission: %+v", got)
+	}
+
+	// Updates from the timed-out attempt cannot touch the new attempt
+	stale := models.StatusUpdate{MissionID: "overdue", Status: models.StatusCompleted, Seq: 2, Attempt: 1}
+	rabbitmq.SaveMissionStatus(missions, stale, models.SourceSoldier)
+	if got, _ := missions.Get("overdue"); got.Status != models.StatusQueued {
+		t.Fatalf("expected new attempt to stay QUEUED, got %s", got.Status)
+	}
+
+	// The second timeout exhausts the republish limit
+	rabbitmq.SaveMissionStatus(missions, models.StatusUpdate{MissionID: "overdue", Status: models.StatusInProgress, Seq: 1, Attempt: 2}, models.SourceSoldier)
+	missions.Update("overdue", func(m *models.Mission) error {
+		past := now.Add(-time.Second)
+		m.Deadline = &past
+		return nil
+	})
+	s.sweep(context.Background(), now)
+
+	if len(published) != 1 {
+		t.Fatalf("expected no further republish, got %d", len(published))
+	}
+	if got, _ := missions.Get("overdue"); got.Status != models.StatusTimedOut {
+		t.Fatalf("expected TIMED_OUT after last attempt, got %s", got.Status)
+	}
+}
+
+func TestSweeper_ReassignsOrdersLostWithTheirSoldier(t *testing.T) {
+	start := time.Now().UTC()
+	now := start.Add(time.Hour)
+	routed := start.Add(time.Minute)
+
+	soldiers := registry.New(15*time.Second, time.Minute)
+	soldiers.Record(models.Heartbeat{Type: models.HeartbeatRegister, SoldierID: "s1", Capabilities: []string{"strike"}}, start)
+	soldiers.Record(models.Heartbeat{Type: models.HeartbeatDeregister, SoldierID: "s1"}, start)
+	soldiers.Record(models.Heartbeat{Type: models.HeartbeatRegister, SoldierID: "s2", Capabilities: []string{"strike"}}, now)
+
+	messages := broker.NewMemory()
+	messages.Declare(rabbitmq.Topology())
+	messages.Declare(broker.Topology{
+		Queues:   []broker.Queue{{Name: "soldier.s2"}},
+		Bindings: []broker.Binding{{Queue: "soldier.s2", Key: "soldier.s2", Exchange: rabbitmq.OrdersExchange}},
+	})
+
+	missions := store.NewMemoryStore()
+	queue := func(id, target, soldier string, capabilities ...string) {
+		missions.Put(&models.Mission{MissionID: id, Status: models.StatusQueued, Attempt: 1, Target: target, Capabilities: capabilities, Soldier: soldier, RoutedAt: &routed})
+	}
+	queue("capable", "", "s1", "strike")   // s1 deregistered, s2 can take it
+	queue("waiting", "soldier:s1", "s1")   // only s1 may run it
+	queue("forgotten", "soldier:s9", "s9") // s9 is unknown and its queue is gone
+
+	s := New(messages, missions, soldiers, time.Minute, 0)
+	s.reassign(context.Background(), now)
+
+	if got, _ := missions.Get("capable"); got.Status != models.StatusQueued || got.Soldier != "s2" || messages.Len("soldier.s2") != 1 {
+		t.Fatalf("expected the mission republished to s2, got %+v", got)
+	}
+	if got, _ := missions.Get("waiting"); got.Status != models.StatusQueued || got.Soldier != "s1" {
+		t.Fatalf("expected the mission to wait for s1, got %+v", got)
+	}
+	if got, _ := missions.Get("forgotten"); got.Status != models.StatusFailed {
+		t.Fatalf("expected the unroutable mission FAILED, got %s", got.Status)
+	}
+}
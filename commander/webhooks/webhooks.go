@@ -0,0 +1,241 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+
+	"mission_control/commander/hub"
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+const (
+	// SignatureHeader carries "sha256=" followed by the hex HMAC-SHA256 of the body
+	SignatureHeader = "X-Mission-Signature"
+	// EventIDHeader carries the event ID so receivers can drop duplicates
+	EventIDHeader = "X-Mission-Event-ID"
+)
+
+// ErrNonPublicAddress is returned for callbacks to loopback, private,
+// link-local and other addresses that are not publicly routable
+var ErrNonPublicAddress = errors.New("address is not publicly routable")
+
+// IsPublic reports whether ip is a publicly routable unicast address
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate()
+}
+
+// publicClient returns a client that only connects to public addresses. The
+// check runs on the resolved address at dial time, so neither DNS nor
+// redirects can point a callback at the commander's own network.
+func publicClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, c syscall.RawConn) error {
+			addr, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublic(addr.Addr()) {
+				return fmt.Errorf("%w: %s", ErrNonPublicAddress, addr.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
+
+// Sign returns the SignatureHeader value of body for the shared secret
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Dispatcher POSTs mission status transitions to the mission's callback_url
+// and to every globally subscribed URL. Failed deliveries are retried with
+// exponential backoff and every attempt is recorded per mission. Callback
+// URLs come from API clients and may only reach public addresses; the
+// subscriptions are configured by the operator and may be internal.
+type Dispatcher struct {
+	missions      store.Store
+	events        *hub.Hub
+	subscriptions []string
+	secret        []byte
+	client        *http.Client // for subscriptions
+	callbacks     *http.Client // for callback URLs, public addresses only
+	maxAttempts   int
+	backoff       time.Duration // Wait before the second attempt, doubled for each further one
+	wg            sync.WaitGroup
+}
+
+// New returns a dispatcher for the events published into events
+func New(missions store.Store, events *hub.Hub, subscriptions []string, secret []byte, maxAttempts int) *Dispatcher {
+	return &Dispatcher{
+		missions:      missions,
+		events:        events,
+		subscriptions: subscriptions,
+		secret:        secret,
+		client:        &http.Client{Timeout: 10 * time.Second},
+		callbacks:     publicClient(10 * time.Second),
+		maxAttempts:   maxAttempts,
+		backoff:       time.Second,
+	}
+}
+
+// Run delivers events until ctx is done, then waits for deliveries in flight
+// to stop. If the dispatcher falls behind the hub it resumes from the last
+// event it handled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	defer d.wg.Wait()
+
+	var lastID uint64
+	for {
+		sub, replay, complete := d.subscribe(lastID)
+		if !complete {
+			log.Printf("Webhook dispatcher missed events after %d", lastID)
+		}
+		for _, e := range replay {
+			d.dispatch(ctx, e)
+			lastID = e.ID
+		}
+		if !d.follow(ctx, sub, &lastID) {
+			return
+		}
+	}
+}
+
+// subscribe subscribes to applied transitions; late updates are not delivered
+func (d *Dispatcher) subscribe(lastID uint64) (*hub.Subscription, []hub.Event, bool) {
+	return d.events.Subscribe(func(e hub.Event) bool { return !e.Late }, lastID)
+}
+
+// follow dispatches live events until ctx is done (false) or the hub drops
+// the subscription (true)
+func (d *Dispatcher) follow(ctx context.Context, sub *hub.Subscription, lastID *uint64) bool {
+	defer sub.Close()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case e, ok := <-sub.C:
+			if !ok {
+				log.Println("Webhook dispatcher fell behind, resuming")
+				return true
+			}
+			d.dispatch(ctx, e)
+			*lastID = e.ID
+		}
+	}
+}
+
+// dispatch starts delivering the event to every URL interested in it. The
+// mission is sent as it was right after the transition, not as it is now.
+func (d *Dispatcher) dispatch(ctx context.Context, e hub.Event) {
+	mission := e.Mission
+	if mission == nil {
+		var err error
+		if mission, err = d.missions.Get(e.MissionID); err != nil {
+			log.Printf("Webhook skipped for mission %s: %v", e.MissionID, err)
+			return
+		}
+	}
+	if mission.CallbackURL == "" && len(d.subscriptions) == 0 {
+		return
+	}
+	body, err := json.Marshal(models.WebhookEvent{
+		ID:      e.ID,
+		Type:    models.WebhookEventType,
+		Event:   e.MissionEvent,
+		Mission: mission,
+	})
+	if err != nil {
+		log.Printf("Failed to encode webhook for mission %s: %v", e.MissionID, err)
+		return
+	}
+	if mission.CallbackURL != "" {
+		d.wg.Go(func() { d.deliver(ctx, d.callbacks, mission.CallbackURL, e, body) })
+	}
+	for _, url := range d.subscriptions {
+		d.wg.Go(func() { d.deliver(ctx, d.client, url, e, body) })
+	}
+}
+
+// deliver POSTs the event until the receiver answers 2xx or the attempts
+// are used up, recording every attempt
+func (d *Dispatcher) deliver(ctx context.Context, client *http.Client, url string, e hub.Event, body []byte) {
+	wait := d.backoff
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		delivery := d.post(ctx, client, url, e, body)
+		delivery.Attempt = attempt
+		if err := d.missions.AddDelivery(delivery); err != nil {
+			log.Printf("Failed to record webhook delivery for mission %s: %v", e.MissionID, err)
+		}
+		if delivery.Delivered {
+			return
+		}
+		log.Printf("Webhook %d for mission %s to %s failed (attempt %d/%d): %s", e.ID, e.MissionID, url, attempt, d.maxAttempts, delivery.Error)
+		if attempt == d.maxAttempts {
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+		wait *= 2
+	}
+}
+
+// post makes a single delivery attempt
+func (d *Dispatcher) post(ctx context.Context, client *http.Client, url string, e hub.Event, body []byte) models.WebhookDelivery {
+	delivery := models.WebhookDelivery{
+		MissionID: e.MissionID,
+		EventID:   e.ID,
+		URL:       url,
+		Status:    e.Status,
+		Timestamp: time.Now().UTC(),
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(d.secret, body))
+	req.Header.Set(EventIDHeader, fmt.Sprint(e.ID))
+
+	resp, err := client.Do(req)
+	delivery.DurationMs = time.Since(delivery.Timestamp).Milliseconds()
+	if err != nil {
+		delivery.Error = err.Error()
+		return delivery
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+
+	delivery.StatusCode = resp.StatusCode
+	delivery.Delivered = resp.StatusCode >= 200 && resp.StatusCode < 300
+	if !delivery.Delivered {
+		delivery.Error = resp.Status
+	}
+	return delivery
+}
@@ -0,0 +1,199 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"mission_control/commander/hub"
+	"mission_control/commander/models"
+	"mission_control/commander/store"
+)
+
+// receiver records the webhooks it accepts and fails the first failures requests
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	received []models.WebhookEvent
+	badSigs  int
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if r.Header.Get(SignatureHeader) != Sign([]byte("secret"), body) {
+		rc.badSigs++
+	}
+	if rc.failures > 0 {
+		rc.failures--
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var event models.WebhookEvent
+	json.Unmarshal(body, &event)
+	rc.received = append(rc.received, event)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.received)
+}
+
+// waitFor polls cond until it holds or a second has passed
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for webhook deliveries")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDispatcher_DeliversSignedEventsWithRetry(t *testing.T) {
+	callback := &receiver{failures: 2}
+	callbackServer := httptest.NewServer(callback)
+	defer callbackServer.Close()
+	global := &receiver{}
+	globalServer := httptest.NewServer(global)
+	defer globalServer.Close()
+
+	events := hub.New(100)
+	missions := events.Wrap(store.NewMemoryStore())
+	missions.Put(&models.Mission{MissionID: "m1", Status: models.StatusCompleted, CallbackURL: callbackServer.URL})
+
+	d := New(missions, events, []string{globalServer.URL}, []byte("secret"), 3)
+	d.callbacks = callbackServer.Client() // the test server listens on loopback
+	d.backoff = time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, _, _ := d.subscribe(0)
+	done := make(chan struct{})
+	go func() {
+		var lastID uint64
+		d.follow(ctx, sub, &lastID)
+		d.wg.Wait()
+		close(done)
+	}()
+
+	// Late updates are not applied transitions and are not delivered
+	missions.AddEvent(models.MissionEvent{MissionID: "m1", Status: models.StatusFailed, Late: true})
+	missions.AddEvent(models.MissionEvent{MissionID: "m1", Status: models.StatusCompleted})
+
+	// Three attempts to the callback, one to the subscription
+	waitFor(t, func() bool {
+		deliveries, _ := missions.Deliveries("m1")
+		return len(deliveries) == 4
+	})
+	cancel()
+	<-done
+
+	if callback.badSigs != 0 || global.badSigs != 0 {
+		t.Fatalf("expected valid signatures, got %d and %d bad ones", callback.badSigs, global.badSigs)
+	}
+	got := callback.received[0]
+	if got.Type != models.WebhookEventType || got.Event.Status != models.StatusCompleted || got.Mission.MissionID != "m1" {
+		t.Fatalf("unexpected webhook: %+v", got)
+	}
+
+	deliveries, _ := missions.Deliveries("m1")
+	perURL := map[string][]models.WebhookDelivery{}
+	for _, delivery := range deliveries {
+		perURL[delivery.URL] = append(perURL[delivery.URL], delivery)
+	}
+	attempts := perURL[callbackServer.URL]
+	if len(attempts) != 3 || attempts[0].StatusCode != http.StatusServiceUnavailable || !attempts[2].Delivered || attempts[2].Attempt != 3 {
+		t.Fatalf("expected two failed attempts and a delivered third, got %+v", attempts)
+	}
+	if len(perURL[globalServer.URL]) != 1 || !perURL[globalServer.URL][0].Delivered {
+		t.Fatalf("expected one delivery to the global subscription, got %+v", perURL[globalServer.URL])
+	}
+}
+
+func TestDispatcher_GivesUpAfterMaxAttempts(t *testing.T) {
+	down := &receiver{failures: 10}
+	server := httptest.NewServer(down)
+	defer server.Close()
+
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", CallbackURL: server.URL})
+	d := New(missions, hub.New(10), nil, []byte("secret"), 2)
+	d.backoff = time.Millisecond
+
+	d.deliver(context.Background(), d.client, server.URL, hub.Event{ID: 1, MissionEvent: models.MissionEvent{MissionID: "m1"}}, []byte(`{}`))
+
+	deliveries, _ := missions.Deliveries("m1")
+	if len(deliveries) != 2 || deliveries[1].Delivered {
+		t.Fatalf("expected two failed attempts, got %+v", deliveries)
+	}
+}
+
+func TestDispatcher_SendsMissionAsOfTheTransition(t *testing.T) {
+	callback := &receiver{}
+	server := httptest.NewServer(callback)
+	defer server.Close()
+
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", Status: models.StatusCompleted, CallbackURL: server.URL})
+	d := New(missions, hub.New(10), nil, []byte("secret"), 1)
+	d.callbacks = server.Client()
+
+	// The mission has moved on since it went IN_PROGRESS
+	snapshot := &models.Mission{MissionID: "m1", Status: models.StatusInProgress, CallbackURL: server.URL}
+	d.dispatch(context.Background(), hub.Event{ID: 1, MissionEvent: models.MissionEvent{MissionID: "m1", Status: models.StatusInProgress, Mission: snapshot}})
+	d.wg.Wait()
+
+	if callback.count() != 1 || callback.received[0].Mission.Status != models.StatusInProgress {
+		t.Fatalf("expected the IN_PROGRESS snapshot, got %+v", callback.received)
+	}
+}
+
+func TestDispatcher_RefusesNonPublicCallbacks(t *testing.T) {
+	callback := &receiver{}
+	server := httptest.NewServer(callback)
+	defer server.Close()
+
+	missions := store.NewMemoryStore()
+	missions.Put(&models.Mission{MissionID: "m1", CallbackURL: server.URL})
+	d := New(missions, hub.New(10), nil, []byte("secret"), 1)
+
+	d.deliver(context.Background(), d.callbacks, server.URL, hub.Event{ID: 1, MissionEvent: models.MissionEvent{MissionID: "m1"}}, []byte(`{}`))
+
+	deliveries, _ := missions.Deliveries("m1")
+	if callback.count() != 0 || len(deliveries) != 1 || !strings.Contains(deliveries[0].Error, ErrNonPublicAddress.Error()) {
+		t.Fatalf("expected the loopback callback to be refused, got %+v", deliveries)
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	cases := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"::1":              false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"fe80::1":          false,
+		"fd00::1":          false,
+		"0.0.0.0":          false,
+		"::ffff:127.0.0.1": false,
+		"224.0.0.1":        false,
+	}
+	for addr, expected := range cases {
+		if got := IsPublic(netip.MustParseAddr(addr)); got != expected {
+			t.Fatalf("%s: expected %v, got %v", addr, expected, got)
+		}
+	}
+}
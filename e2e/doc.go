@@ -0,0 +1,3 @@
+// Package e2e runs the commander and the soldier together on the memory
+// broker. It only holds tests; run them with go test from this directory.
+package e2e
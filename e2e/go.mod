@@ -0,0 +1,25 @@
+module mission_control/e2e
+
+go 1.25.5
+
+require (
+	github.com/golang-jwt/jwt/v5 v5.3.0
+	mission_control/broker v0.0.0
+	mission_control/commander v0.0.0
+	mission_control/soldier v0.0.0
+)
+
+require (
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/rabbitmq/amqp091-go v1.10.0 // indirect
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.2 // indirect
+	go.etcd.io/bbolt v1.4.3 // indirect
+	golang.org/x/sys v0.29.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+)
+
+replace (
+	mission_control/broker => ../broker
+	mission_control/commander => ../commander
+	mission_control/soldier => ../soldier
+)
@@ -0,0 +1,175 @@
+package e2e
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/commander/handlers"
+	"mission_control/commander/models"
+	"mission_control/commander/orders"
+	"mission_control/commander/rabbitmq"
+	"mission_control/commander/registry"
+	"mission_control/commander/store"
+	"mission_control/soldier/auth"
+	"mission_control/soldier/config"
+	"mission_control/soldier/execute_mission"
+	soldiermodels "mission_control/soldier/models"
+	soldierrabbitmq "mission_control/soldier/rabbitmq"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// commander runs the commander's status consumer on b and serves the mission endpoints
+type commander struct {
+	missions *store.MemoryStore
+	create   http.HandlerFunc
+	cancel   http.HandlerFunc
+}
+
+func startCommander(ctx context.Context, t *testing.T, b broker.Broker) *commander {
+	t.Helper()
+	if err := b.Declare(rabbitmq.Topology()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	missions := store.NewMemoryStore()
+	go rabbitmq.ConsumeStatusUpdates(ctx, b, missions, 1024)
+	go rabbitmq.ConsumeDeadLetters(ctx, b, missions)
+
+	return &commander{
+		missions: missions,
+		create:   handlers.CreateMissionHandler(b, missions, missions, orders.NewDefaultRegistry(), registry.New(time.Minute, time.Hour)),
+		cancel:   handlers.CancelMissionHandler(b, missions),
+	}
+}
+
+// post creates a mission and returns its ID
+func (c *commander) post(t *testing.T, body string) string {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	c.create(rr, httptest.NewRequest("POST", "/missions", strings.NewReader(body)))
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var created map[string]string
+	json.Unmarshal(rr.Body.Bytes(), &created)
+	return created["mission_id"]
+}
+
+// await waits until the mission is in one of the statuses and returns it
+func (c *commander) await(t *testing.T, id string, statuses ...string) *models.Mission {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		mission, err := c.missions.Get(id)
+		if err == nil && slices.Contains(statuses, mission.Status) {
+			return mission
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected mission %s to become one of %v, got %+v", id, statuses, mission)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startSoldier consumes orders and control messages on b like the soldier's
+// main loop, executing every mission until ctx is done
+func startSoldier(ctx context.Context, t *testing.T, b broker.Broker, soldierID string) {
+	t.Helper()
+	if err := b.Declare(soldierrabbitmq.Topology()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user": config.SOLDIER_USER,
+		"role": config.SOLDIER_ACCESS,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+	signed, _ := token.SignedString(config.GetJWTSecret())
+	auth.SetTokens(signed, "")
+
+	cancellations := execute_mission.NewCancellations()
+	controls := soldierrabbitmq.ConsumeControl(ctx, b, soldierID)
+	go func() {
+		for d := range controls {
+			var msg soldiermodels.ControlMessage
+			if json.Unmarshal(d.Body, &msg) == nil && msg.Type == soldiermodels.ControlCancel {
+				cancellations.Cancel(msg.MissionID)
+			}
+		}
+	}()
+
+	msgs := soldierrabbitmq.ConsumeOrders(ctx, b, soldierID, "", 2)
+	go func() {
+		for d := range msgs {
+			var mission soldiermodels.Mission
+			if err := json.Unmarshal(d.Body, &mission); err != nil {
+				soldierrabbitmq.DeadLetterOrder(b, d, err)
+				continue
+			}
+			missionCtx, done, ok := cancellations.Start(ctx, mission.ID)
+			if !ok {
+				d.Ack(false)
+				continue
+			}
+			go func() {
+				defer done()
+				if err := execute_mission.ExecuteMission(missionCtx, mission, b); err != nil {
+					d.Nack(false, true)
+					return
+				}
+				d.Ack(false)
+			}()
+		}
+	}()
+}
+
+func TestMission_RunsOnSoldier(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := broker.NewMemory()
+	c := startCommander(ctx, t, b)
+	startSoldier(ctx, t, b, "s1")
+
+	id := c.post(t, `{"order":"Recon"}`)
+	mission := c.await(t, id, models.StatusCompleted, models.StatusFailed)
+	if mission.StartedAt == nil || mission.FinishedAt == nil {
+		t.Fatalf("expected the soldier's timestamps, got %+v", mission)
+	}
+	events, _ := c.missions.Events(id)
+	if len(events) < 3 || events[1].Status != models.StatusInProgress {
+		t.Fatalf("expected QUEUED, IN_PROGRESS and the final status, got %+v", events)
+	}
+	if b.Len(rabbitmq.OrdersQueue) != 0 || b.Len(rabbitmq.StatusQueue) != 0 {
+		t.Fatal("expected every message to be settled")
+	}
+}
+
+func TestMission_CancelledWhileRunning(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	b := broker.NewMemory()
+	c := startCommander(ctx, t, b)
+	startSoldier(ctx, t, b, "s1")
+
+	id := c.post(t, `{"order":"Recon"}`)
+	c.await(t, id, models.StatusInProgress)
+
+	req := httptest.NewRequest("POST", "/missions/"+id+"/cancel", nil)
+	req.SetPathValue("id", id)
+	rr := httptest.NewRecorder()
+	c.cancel(rr, req)
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	mission := c.await(t, id, models.StatusCancelled)
+	if mission.Error == nil || mission.Error.Code != soldiermodels.ErrorCodeCancelled {
+		t.Fatalf("expected the soldier's cancellation error, got %+v", mission.Error)
+	}
+}
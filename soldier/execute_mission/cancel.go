@@ -0,0 +1,99 @@
+package execute_mission
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrMissionCancelled is the context cause used when the commander cancels a mission
+	ErrMissionCancelled = errors.New("mission cancelled by commander")
+	// ErrShuttingDown is the context cause used when the soldier stops missions it could not drain
+	ErrShuttingDown = errors.New("soldier shutting down")
+)
+
+// cancelledRetention is how long cancelled mission IDs are remembered so the
+// orders still waiting in the queue can be skipped on receipt
+const cancelledRetention = 24 * time.Hour
+
+// Cancellations tracks running missions so control messages can abort them,
+// and remembers cancelled missions that have not been received yet.
+type Cancellations struct {
+	mu        sync.Mutex
+	running   map[string]context.CancelCauseFunc
+	cancelled map[string]time.Time
+}
+
+// NewCancellations returns an empty cancellation registry
+func NewCancellations() *Cancellations {
+	return &Cancellations{
+		running:   make(map[string]context.CancelCauseFunc),
+		cancelled: make(map[string]time.Time),
+	}
+}
+
+// Start registers a mission as running and returns its context.
+// ok is false if the mission was cancelled before it arrived; it must then be skipped.
+// done must be called once the mission has finished.
+func (c *Cancellations) Start(parent context.Context, missionID string) (ctx context.Context, done func(), ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, cancelled := c.cancelled[missionID]; cancelled {
+		return nil, nil, false
+	}
+	ctx, cancel := context.WithCancelCause(parent)
+	c.running[missionID] = cancel
+	done = func() {
+		c.mu.Lock()
+		delete(c.running, missionID)
+		c.mu.Unlock()
+		cancel(nil)
+	}
+	return ctx, done, true
+}
+
+// Running returns the IDs of the missions currently executing, sorted
+func (c *Cancellations) Running() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ids := make([]string, 0, len(c.running))
+	for id := range c.running {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Cancel aborts the mission if it is running and remembers it so a later
+// delivery is skipped
+func (c *Cancellations) Cancel(missionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for id, at := range c.cancelled {
+		if now.Sub(at) > cancelledRetention {
+			delete(c.cancelled, id)
+		}
+	}
+	c.cancelled[missionID] = now
+	if cancel, ok := c.running[missionID]; ok {
+		cancel(ErrMissionCancelled)
+	}
+}
+
+// CancelAll aborts every running mission with cause. Unlike Cancel it does not
+// remember them, so they run again when redelivered.
+func (c *Cancellations) CancelAll(cause error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, cancel := range c.running {
+		cancel(cause)
+	}
+}
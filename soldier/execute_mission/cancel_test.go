@@ -0,0 +1,72 @@
+package execute_mission
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCancellations_AbortsRunningMission(t *testing.T) {
+	c := NewCancellations()
+
+	ctx, done, ok := c.Start(context.Background(), "m1")
+	if !ok {
+		t.Fatal("expected mission to start")
+	}
+	defer done()
+
+	c.Cancel("m1")
+
+	<-ctx.Done()
+	if context.Cause(ctx) != ErrMissionCancelled {
+		t.Fatalf("expected ErrMissionCancelled cause, got %v", context.Cause(ctx))
+	}
+}
+
+func TestCancellations_SkipsMissionCancelledBeforeReceipt(t *testing.T) {
+	c := NewCancellations()
+	c.Cancel("m1")
+
+	if _, _, ok := c.Start(context.Background(), "m1"); ok {
+		t.Fatal("expected cancelled mission to be skipped")
+	}
+	_, done, ok := c.Start(context.Background(), "m2")
+	if !ok {
+		t.Fatal("expected other missions to start")
+	}
+	done()
+}
+
+func TestCancellations_Running(t *testing.T) {
+	c := NewCancellations()
+
+	_, doneB, _ := c.Start(context.Background(), "b")
+	_, doneA, _ := c.Start(context.Background(), "a")
+	if running := c.Running(); len(running) != 2 || running[0] != "a" || running[1] != "b" {
+		t.Fatalf("expected [a b], got %v", running)
+	}
+
+	doneA()
+	doneB()
+	if running := c.Running(); len(running) != 0 {
+		t.Fatalf("expected no running missions, got %v", running)
+	}
+}
+
+func TestCancellations_CancelAll(t *testing.T) {
+	c := NewCancellations()
+	ctx, done, _ := c.Start(context.Background(), "m1")
+	defer done()
+
+	c.CancelAll(ErrShuttingDown)
+
+	<-ctx.Done()
+	if context.Cause(ctx) != ErrShuttingDown {
+		t.Fatalf("expected ErrShuttingDown cause, got %v", context.Cause(ctx))
+	}
+	// Missions stopped by shutdown are not remembered as cancelled
+	if _, done, ok := c.Start(context.Background(), "m1"); !ok {
+		t.Fatal("expected the mission to run again when redelivered")
+	} else {
+		done()
+	}
+}
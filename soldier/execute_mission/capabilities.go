@@ -0,0 +1,48 @@
+package execute_mission
+
+import (
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/soldier/models"
+	"mission_control/soldier/rabbitmq"
+)
+
+// MissingCapabilities returns the capabilities the mission requires that the soldier lacks
+func MissingCapabilities(m models.Mission, capabilities []string) []string {
+	var missing []string
+	for _, capability := range m.Capabilities {
+		if !slices.Contains(capabilities, capability) {
+			missing = append(missing, capability)
+		}
+	}
+	return missing
+}
+
+// RejectIncapable reports a mission the soldier cannot serve as FAILED, so it
+// does not wait in QUEUED until someone notices. It returns the publish error,
+// if any, so the order can be requeued.
+func RejectIncapable(m models.Mission, missing []string, publisher broker.Broker) error {
+	finishedAt := time.Now().UTC()
+	status := models.StatusUpdate{
+		MissionID:  m.ID,
+		Status:     "FAILED",
+		Seq:        firstSeq(),
+		Attempt:    m.Attempt,
+		FinishedAt: &finishedAt,
+		Error: &models.MissionError{
+			Code:    models.ErrorCodeIncapable,
+			Message: "soldier lacks capabilities: " + strings.Join(missing, ", "),
+		},
+	}
+	body, _ := json.Marshal(status)
+	if err := rabbitmq.PublishWithRetry(publisher, rabbitmq.StatusQueue, body); err != nil {
+		return err
+	}
+	log.Printf("Mission %s rejected, missing capabilities %v", m.ID, missing)
+	return nil
+}
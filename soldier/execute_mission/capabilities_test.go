@@ -0,0 +1,45 @@
+package execute_mission
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"mission_control/broker"
+	"mission_control/soldier/models"
+	"mission_control/soldier/rabbitmq"
+)
+
+func TestMissingCapabilities(t *testing.T) {
+	m := models.Mission{Capabilities: []string{"night-vision", "recon", "strike"}}
+
+	missing := MissingCapabilities(m, []string{"recon"})
+	if !slices.Equal(missing, []string{"night-vision", "strike"}) {
+		t.Fatalf("expected night-vision and strike to be missing, got %v", missing)
+	}
+	if missing := MissingCapabilities(models.Mission{}, nil); missing != nil {
+		t.Fatalf("missions without requirements can run anywhere, got %v", missing)
+	}
+}
+
+func TestRejectIncapable_MemoryBroker(t *testing.T) {
+	b := broker.NewMemory()
+	if err := b.Declare(rabbitmq.Topology()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	m := models.Mission{ID: "m1", Attempt: 2, Capabilities: []string{"strike"}}
+	if err := RejectIncapable(m, []string{"strike"}, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	d, ok := b.Get(rabbitmq.StatusQueue)
+	if !ok {
+		t.Fatal("expected a status update in the status queue")
+	}
+	var status models.StatusUpdate
+	json.Unmarshal(d.Body, &status)
+	if status.MissionID != "m1" || status.Status != "FAILED" || status.Attempt != 2 || status.Error == nil || status.Error.Code != models.ErrorCodeIncapable {
+		t.Fatalf("unexpected status update: %+v", status)
+	}
+}
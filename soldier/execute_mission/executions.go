@@ -0,0 +1,75 @@
+package execute_mission
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"mission_control/soldier/models"
+)
+
+// finishedRetention is how long finished mission attempts are remembered so
+// redelivered orders for them are recognised
+const finishedRetention = 24 * time.Hour
+
+// Executions makes execution idempotent per mission attempt: an order that is
+// delivered again while its attempt is running or after it finished here is
+// skipped. Attempts that end without a final status are forgotten so their
+// requeued order runs again.
+type Executions struct {
+	mu       sync.Mutex
+	running  map[string]bool
+	finished map[string]time.Time
+}
+
+// NewExecutions returns an empty execution registry
+func NewExecutions() *Executions {
+	return &Executions{
+		running:  make(map[string]bool),
+		finished: make(map[string]time.Time),
+	}
+}
+
+// executionKey identifies one attempt of a mission; the commander republishes
+// retried missions with the same ID and a new attempt
+func executionKey(m models.Mission) string {
+	return fmt.Sprintf("%s/%d", m.ID, m.Attempt)
+}
+
+// Begin claims the mission attempt. It returns false for duplicates of an
+// attempt that is running or has finished on this soldier.
+func (e *Executions) Begin(m models.Mission) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := executionKey(m)
+	if _, done := e.finished[key]; done || e.running[key] {
+		return false
+	}
+	e.running[key] = true
+	return true
+}
+
+// Finish records that the attempt's final status has been published
+func (e *Executions) Finish(m models.Mission) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := time.Now()
+	for key, at := range e.finished {
+		if now.Sub(at) > finishedRetention {
+			delete(e.finished, key)
+		}
+	}
+	key := executionKey(m)
+	delete(e.running, key)
+	e.finished[key] = now
+}
+
+// Abort forgets an attempt that did not finish, so a redelivery runs it again
+func (e *Executions) Abort(m models.Mission) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.running, executionKey(m))
+}
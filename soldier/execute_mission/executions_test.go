@@ -0,0 +1,40 @@
+package execute_mission
+
+import (
+	"testing"
+
+	"mission_control/soldier/models"
+)
+
+func TestExecutions_SkipsDuplicateDeliveries(t *testing.T) {
+	e := NewExecutions()
+	m := models.Mission{ID: "m1", Attempt: 1}
+
+	if !e.Begin(m) {
+		t.Fatal("expected the first delivery to run")
+	}
+	if e.Begin(m) {
+		t.Fatal("expected a delivery of a running attempt to be skipped")
+	}
+
+	e.Finish(m)
+	if e.Begin(m) {
+		t.Fatal("expected a delivery of a finished attempt to be skipped")
+	}
+
+	// A retried mission comes back as a new attempt
+	if !e.Begin(models.Mission{ID: "m1", Attempt: 2}) {
+		t.Fatal("expected a new attempt to run")
+	}
+}
+
+func TestExecutions_RunsAbortedAttemptAgain(t *testing.T) {
+	e := NewExecutions()
+	m := models.Mission{ID: "m1", Attempt: 1}
+
+	e.Begin(m)
+	e.Abort(m)
+	if !e.Begin(m) {
+		t.Fatal("expected a requeued attempt to run again")
+	}
+}
@@ -0,0 +1,52 @@
+package execute_mission
+
+import (
+	"context"
+	"sync"
+)
+
+// Pool runs missions on a fixed number of workers. The soldier prefetches
+// only as many orders as the pool has workers, so orders it cannot start yet
+// stay in the queue for other soldiers.
+type Pool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewPool returns a pool of size workers
+func NewPool(size int) *Pool {
+	return &Pool{slots: make(chan struct{}, size)}
+}
+
+// Go runs f on a free worker, waiting for one if all are busy
+func (p *Pool) Go(f func()) {
+	p.slots <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer func() {
+			<-p.slots
+			p.wg.Done()
+		}()
+		f()
+	}()
+}
+
+// Load returns how many workers are busy and how many there are
+func (p *Pool) Load() (busy, size int) {
+	return len(p.slots), cap(p.slots)
+}
+
+// Wait blocks until every started mission has returned or ctx is done
+func (p *Pool) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,64 @@
+package execute_mission
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPool_BoundsConcurrency(t *testing.T) {
+	p := NewPool(2)
+	release := make(chan struct{})
+	var running, peak atomic.Int32
+
+	started := make(chan struct{})
+	go func() {
+		for range 4 {
+			p.Go(func() {
+				n := running.Add(1)
+				for {
+					if old := peak.Load(); n <= old || peak.CompareAndSwap(old, n) {
+						break
+					}
+				}
+				<-release
+				running.Add(-1)
+			})
+		}
+		close(started)
+	}()
+
+	// Two missions run, the third waits for a free worker
+	time.Sleep(20 * time.Millisecond)
+	if busy, size := p.Load(); busy != 2 || size != 2 {
+		t.Fatalf("expected 2 of 2 workers busy, got %d of %d", busy, size)
+	}
+	select {
+	case <-started:
+		t.Fatal("expected Go to wait while every worker is busy")
+	default:
+	}
+
+	close(release)
+	<-started
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if busy, _ := p.Load(); busy != 0 || peak.Load() != 2 {
+		t.Fatalf("expected at most 2 concurrent missions and none left, got peak %d, busy %d", peak.Load(), busy)
+	}
+}
+
+func TestPool_WaitTimesOut(t *testing.T) {
+	p := NewPool(1)
+	release := make(chan struct{})
+	defer close(release)
+	p.Go(func() { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected the wait to time out while a mission runs, got %v", err)
+	}
+}
@@ -0,0 +1,107 @@
+package execute_mission
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"mission_control/soldier/models"
+)
+
+// maxProgressMessageLength keeps progress messages short
+const maxProgressMessageLength = 200
+
+// ProgressReporter publishes PROGRESS updates for a running mission. Reports
+// arriving sooner than interval after the last published one are dropped,
+// except the first and a final 100%, so chatty executors cannot flood
+// status_queue. It shares the sequence counter of the mission's other status
+// updates so the commander keeps them in order. Updates are published in the
+// background so a slow broker does not hold up the mission; reports made while
+// one is still being published are coalesced into the latest.
+type ProgressReporter struct {
+	mu        sync.Mutex
+	missionID string
+	attempt   int
+	seq       *int64
+	interval  time.Duration
+	last      time.Time
+	publish   func(body []byte)
+	sending   bool   // a background publish is running
+	pending   []byte // the latest update waiting for it
+}
+
+// NewProgressReporter returns a reporter for the mission whose status updates use seq
+func NewProgressReporter(missionID string, attempt int, seq *int64, interval time.Duration, publish func(body []byte)) *ProgressReporter {
+	return &ProgressReporter{
+		missionID: missionID,
+		attempt:   attempt,
+		seq:       seq,
+		interval:  interval,
+		publish:   publish,
+	}
+}
+
+// Report publishes the mission's progress in percent (clamped to 0-100) with
+// a short message. It returns false if the report was throttled.
+func (p *ProgressReporter) Report(percent int, message string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	percent = min(max(percent, 0), 100)
+	now := time.Now()
+	if !p.last.IsZero() && now.Sub(p.last) < p.interval && percent < 100 {
+		return false
+	}
+	message = truncate(message, maxProgressMessageLength)
+	p.last = now
+	*p.seq++
+
+	update := models.StatusUpdate{
+		MissionID: p.missionID,
+		Status:    models.StatusProgress,
+		Seq:       *p.seq,
+		Attempt:   p.attempt,
+		Progress:  &models.Progress{Percent: percent, Message: message},
+	}
+	body, err := json.Marshal(update)
+	if err != nil {
+		log.Println("Got an error while Marshal mission progress: ", err.Error())
+		return false
+	}
+	if p.sending {
+		p.pending = body
+		return true
+	}
+	p.sending = true
+	go p.send(body)
+	return true
+}
+
+// send publishes body, then whatever update was reported meanwhile
+func (p *ProgressReporter) send(body []byte) {
+	for {
+		p.publish(body)
+
+		p.mu.Lock()
+		body, p.pending = p.pending, nil
+		if body == nil {
+			p.sending = false
+			p.mu.Unlock()
+			return
+		}
+		p.mu.Unlock()
+	}
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
@@ -0,0 +1,108 @@
+package execute_mission
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"mission_control/soldier/models"
+)
+
+// collect returns a publish func sending updates to the returned channel
+func collect() (func(body []byte), chan models.StatusUpdate) {
+	published := make(chan models.StatusUpdate, 10)
+	return func(body []byte) {
+		var update models.StatusUpdate
+		json.Unmarshal(body, &update)
+		published <- update
+	}, published
+}
+
+// next returns the next published update or fails the test
+func next(t *testing.T, published chan models.StatusUpdate) models.StatusUpdate {
+	t.Helper()
+	select {
+	case update := <-published:
+		return update
+	case <-time.After(time.Second):
+		t.Fatal("expected a published update")
+	}
+	return models.StatusUpdate{}
+}
+
+func TestProgressReporter_Throttles(t *testing.T) {
+	publish, published := collect()
+	seq := int64(1) // IN_PROGRESS was sent with seq 1
+	reporter := NewProgressReporter("m1", 2, &seq, time.Hour, publish)
+
+	if !reporter.Report(10, "started") {
+		t.Fatalf("first report must be published")
+	}
+	first := next(t, published)
+	if reporter.Report(50, "halfway") {
+		t.Fatalf("report within the interval must be throttled")
+	}
+	if !reporter.Report(150, "done") {
+		t.Fatalf("completion must always be published")
+	}
+
+	last := next(t, published)
+	if last.Status != models.StatusProgress || last.Attempt != 2 || last.Progress.Percent != 100 {
+		t.Fatalf("unexpected progress update: %+v", last)
+	}
+	if first.Seq != 2 || last.Seq != 3 || seq != 3 {
+		t.Fatalf("expected progress to continue the mission sequence, got %d, %d (counter %d)", first.Seq, last.Seq, seq)
+	}
+}
+
+func TestProgressReporter_CoalescesWhilePublishing(t *testing.T) {
+	release := make(chan struct{})
+	publish, published := collect()
+	seq := int64(1)
+	reporter := NewProgressReporter("m1", 1, &seq, 0, func(body []byte) {
+		<-release
+		publish(body)
+	})
+
+	// A blocked publish does not hold up reporting
+	reported := make(chan struct{})
+	go func() {
+		reporter.Report(10, "")
+		reporter.Report(20, "")
+		reporter.Report(30, "")
+		close(reported)
+	}()
+	select {
+	case <-reported:
+	case <-time.After(time.Second):
+		t.Fatal("Report blocked on a slow publish")
+	}
+	close(release)
+
+	if got := next(t, published).Progress.Percent; got != 10 {
+		t.Fatalf("expected the first report, got %d%%", got)
+	}
+	if got := next(t, published).Progress.Percent; got != 30 {
+		t.Fatalf("expected only the latest pending report, got %d%%", got)
+	}
+	select {
+	case update := <-published:
+		t.Fatalf("expected the 20%% report to be coalesced, got %d%%", update.Progress.Percent)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestProgressReporter_TruncatesOnRuneBoundary(t *testing.T) {
+	publish, published := collect()
+	seq := int64(1)
+	reporter := NewProgressReporter("m1", 1, &seq, time.Hour, publish)
+
+	// "é" is two bytes, so the limit falls inside the last rune
+	reporter.Report(10, "a"+strings.Repeat("é", maxProgressMessageLength))
+	message := next(t, published).Progress.Message
+	if len(message) > maxProgressMessageLength || !utf8.ValidString(message) || len(message) != maxProgressMessageLength-1 {
+		t.Fatalf("expected a valid message of %d bytes, got %d bytes", maxProgressMessageLength-1, len(message))
+	}
+}
@@ -3,19 +3,37 @@ package execute_mission
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"math/rand"
 	"time"
 
+	"mission_control/broker"
 	"mission_control/soldier/auth"
+	"mission_control/soldier/config"
 	"mission_control/soldier/models"
 	"mission_control/soldier/rabbitmq"
-
-	amqp "github.com/rabbitmq/amqp091-go"
 )
 
-// ExecuteMission runs the mission logic and sends status updates
-func ExecuteMission(ctx context.Context, m models.Mission, ch *amqp.Channel) {
+// ErrNotFinished is returned when a mission could not be run to a published
+// final status and its order should be requeued
+var ErrNotFinished = errors.New("mission not finished")
+
+// firstSeq starts the sequence numbers of one execution. They derive from the
+// clock, so when a redelivered order runs again, here or on another soldier,
+// its updates are newer than those of the interrupted run.
+func firstSeq() int64 {
+	return time.Now().UnixMicro()
+}
+
+// ExecuteMission runs the mission logic and sends status updates.
+// If ctx is cancelled (see Cancellations) the mission stops early and
+// reports CANCELLED, unless the soldier is shutting down: then no final status
+// is reported, so the requeued order runs again. It returns nil once the final
+// status has been confirmed by the broker and ErrNotFinished if the soldier
+// could not authenticate, was shut down or could not publish the final status.
+func ExecuteMission(ctx context.Context, m models.Mission, publisher broker.Broker) error {
 
 	log.Println("ExecuteMission started")
 	// Validate soldier token before executing mission
@@ -25,43 +43,101 @@ func ExecuteMission(ctx context.Context, m models.Mission, ch *amqp.Channel) {
 		// Log authentication failure and mark mission unfinished
 		log.Println("Got an error while ValidateSoldier: ", err.Error())
 		log.Printf("Mission %s is unfinished due to Authentication error: %s\n", m.ID, err.Error())
-	} else {
-		// Prepare initial mission IN_PROGRESS status
-		status := struct {
-			MissionID string `json:"mission_id"`
-			Status    string `json:"status"`
-		}{MissionID: m.ID, Status: "IN_PROGRESS"}
-
-		// Convert status to JSON
-		body, err := json.Marshal(status)
-
-		if err != nil {
-			// Log serialization failure
-			log.Println("Got an error while Marshal mission status: ", err.Error())
-		}
+		return fmt.Errorf("%w: %v", ErrNotFinished, err)
+	}
+
+	// Prepare initial mission IN_PROGRESS status
+	startedAt := time.Now().UTC()
+	status := models.StatusUpdate{MissionID: m.ID, Status: "IN_PROGRESS", Seq: firstSeq(), Attempt: m.Attempt, StartedAt: &startedAt}
+
+	// Convert status to JSON
+	body, err := json.Marshal(status)
+
+	if err != nil {
+		// Log serialization failure
+		log.Println("Got an error while Marshal mission status: ", err.Error())
+	}
 
-		// Publish IN_PROGRESS status with retry logic
-		rabbitmq.PublishWithRetry(ch, rabbitmq.StatusQueue, body)
+	// Publish IN_PROGRESS status with retry logic
+	rabbitmq.PublishWithRetry(publisher, rabbitmq.StatusQueue, body)
 
-		// Simulate mission execution time
-		delay := time.Duration(1+rand.Intn(5)) * time.Second
-		time.Sleep(delay)
+	if m.Type != "" {
+		log.Printf("Mission %s executing %s order with params %s", m.ID, m.Type, m.Params)
+	}
+
+	// Progress updates share the sequence of the mission's status updates
+	progress := NewProgressReporter(m.ID, m.Attempt, &status.Seq, config.GetProgressInterval(), func(body []byte) {
+		rabbitmq.PublishWithRetry(publisher, rabbitmq.StatusQueue, body)
+	})
 
+	// Simulate mission execution time, unless the commander cancels it
+	delay := time.Duration(1+rand.Intn(5)) * time.Second
+	outcome := "COMPLETED"
+	if err := simulateWork(ctx, delay, progress); errors.Is(err, ErrShuttingDown) {
+		log.Printf("Mission %s interrupted: %v", m.ID, err)
+		return fmt.Errorf("%w: %v", ErrNotFinished, err)
+	} else if err != nil {
+		log.Printf("Mission %s aborted: %v", m.ID, err)
+		outcome = "CANCELLED"
+		status.Error = &models.MissionError{Code: models.ErrorCodeCancelled, Message: err.Error()}
+	} else if rand.Float32() > 0.9 {
 		// Randomly determine mission outcome
-		outcome := "COMPLETED"
-		if rand.Float32() > 0.9 {
-			outcome = "FAILED"
-		}
+		outcome = "FAILED"
+		status.Error = &models.MissionError{Code: models.ErrorCodeFailed, Message: "simulated mission failure"}
+	} else {
+		status.Result = missionReport(m, delay)
+	}
+
+	// Update final mission status
+	finishedAt := time.Now().UTC()
+	status.Status = outcome
+	status.Seq++
+	status.FinishedAt = &finishedAt
+	body, _ = json.Marshal(status)
 
-		// Update final mission status
-		status.Status = outcome
-		body, _ = json.Marshal(status)
+	// Publish final mission status update; the order is only done once it is confirmed
+	if err := rabbitmq.PublishWithRetry(publisher, rabbitmq.StatusQueue, body); err != nil {
+		log.Printf("Mission %s finished %s but its status could not be published: %v", m.ID, outcome, err)
+		return fmt.Errorf("%w: %v", ErrNotFinished, err)
+	}
+
+	// Log the mission result
+	log.Printf("Mission %s finished: %s\n", m.ID, outcome)
+	return nil
+}
 
-		// Publish final mission status update
-		rabbitmq.PublishWithRetry(ch, rabbitmq.StatusQueue, body)
+// progressStep is how often simulated work reports progress; the reporter throttles further
+const progressStep = 250 * time.Millisecond
 
-		// Log the mission result
-		log.Printf("Mission %s finished: %s\n", m.ID, outcome)
+// simulateWork waits for delay while reporting progress. It returns the
+// cancellation cause if ctx ends first.
+func simulateWork(ctx context.Context, delay time.Duration, progress *ProgressReporter) error {
+	started := time.Now()
+	finished := time.After(delay)
+	ticker := time.NewTicker(progressStep)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-finished:
+			progress.Report(100, "done")
+			return nil
+		case <-ticker.C:
+			percent := int(time.Since(started) * 100 / delay)
+			progress.Report(percent, "working")
+		case <-ctx.Done():
+			return context.Cause(ctx)
+		}
 	}
+}
 
+// missionReport is the result of a completed mission
+func missionReport(m models.Mission, took time.Duration) json.RawMessage {
+	report, _ := json.Marshal(map[string]any{
+		"order":       m.Order,
+		"type":        m.Type,
+		"params":      m.Params,
+		"duration_ms": took.Milliseconds(),
+	})
+	return report
 }
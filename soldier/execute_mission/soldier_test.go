@@ -0,0 +1,120 @@
+package execute_mission
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/soldier/auth"
+	"mission_control/soldier/config"
+	"mission_control/soldier/models"
+	"mission_control/soldier/rabbitmq"
+
+	jwt "github.com/golang-jwt/jwt/v5"
+)
+
+// loginSoldier stores a valid soldier access token, as a login would
+func loginSoldier(t *testing.T) {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"user": config.SOLDIER_USER,
+		"role": config.SOLDIER_ACCESS,
+		"exp":  time.Now().Add(time.Hour).Unix(),
+	})
+	signed, err := token.SignedString(config.GetJWTSecret())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	auth.SetTokens(signed, "")
+	t.Cleanup(func() { auth.SetTokens("", "") })
+}
+
+// statusBroker returns a memory broker with the shared topology declared
+func statusBroker(t *testing.T) *broker.Memory {
+	t.Helper()
+	b := broker.NewMemory()
+	if err := b.Declare(rabbitmq.Topology()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return b
+}
+
+// statusUpdates takes every status update published so far
+func statusUpdates(b *broker.Memory) []models.StatusUpdate {
+	var updates []models.StatusUpdate
+	for d, ok := b.Get(rabbitmq.StatusQueue); ok; d, ok = b.Get(rabbitmq.StatusQueue) {
+		var update models.StatusUpdate
+		json.Unmarshal(d.Body, &update)
+		updates = append(updates, update)
+	}
+	return updates
+}
+
+func TestExecuteMission_MemoryBroker(t *testing.T) {
+	loginSoldier(t)
+	b := statusBroker(t)
+
+	m := models.Mission{ID: "m1", Attempt: 2, Order: "recon"}
+	if err := ExecuteMission(context.Background(), m, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updates := statusUpdates(b)
+	first, last := updates[0], updates[len(updates)-1]
+	if first.Status != "IN_PROGRESS" || first.StartedAt == nil {
+		t.Fatalf("expected IN_PROGRESS first, got %+v", first)
+	}
+	if (last.Status != "COMPLETED" && last.Status != "FAILED") || last.FinishedAt == nil {
+		t.Fatalf("expected a final status last, got %+v", last)
+	}
+	for i, update := range updates {
+		if update.MissionID != "m1" || update.Attempt != 2 {
+			t.Fatalf("expected updates of attempt 2 of m1, got %+v", update)
+		}
+		if i > 0 && update.Seq <= updates[i-1].Seq {
+			t.Fatalf("expected increasing sequence numbers, got %d after %d", update.Seq, updates[i-1].Seq)
+		}
+	}
+}
+
+func TestExecuteMission_Cancelled(t *testing.T) {
+	loginSoldier(t)
+	b := statusBroker(t)
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("cancelled by the commander"))
+	if err := ExecuteMission(ctx, models.Mission{ID: "m1", Attempt: 1}, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updates := statusUpdates(b)
+	if len(updates) != 2 || updates[1].Status != "CANCELLED" || updates[1].Error == nil || updates[1].Error.Code != models.ErrorCodeCancelled {
+		t.Fatalf("expected IN_PROGRESS then CANCELLED, got %+v", updates)
+	}
+}
+
+func TestExecuteMission_NotFinished(t *testing.T) {
+	b := statusBroker(t)
+
+	// Without a token the mission is not started
+	if err := ExecuteMission(context.Background(), models.Mission{ID: "m1"}, b); !errors.Is(err, ErrNotFinished) {
+		t.Fatalf("expected ErrNotFinished, got %v", err)
+	}
+	if updates := statusUpdates(b); len(updates) != 0 {
+		t.Fatalf("expected no status updates, got %+v", updates)
+	}
+
+	// Interrupted by a shutdown, it reports no final status so the order runs again
+	loginSoldier(t)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrShuttingDown)
+	if err := ExecuteMission(ctx, models.Mission{ID: "m1"}, b); !errors.Is(err, ErrNotFinished) {
+		t.Fatalf("expected ErrNotFinished, got %v", err)
+	}
+	if updates := statusUpdates(b); len(updates) != 1 || updates[0].Status != "IN_PROGRESS" {
+		t.Fatalf("expected only IN_PROGRESS, got %+v", updates)
+	}
+}
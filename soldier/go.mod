@@ -5,4 +5,7 @@ go 1.25.5
 require (
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/rabbitmq/amqp091-go v1.10.0
+	mission_control/broker v0.0.0
 )
+
+replace mission_control/broker => ../broker
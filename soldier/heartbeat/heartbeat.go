@@ -0,0 +1,87 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"mission_control/broker"
+	"mission_control/soldier/models"
+	"mission_control/soldier/rabbitmq"
+)
+
+// Sender registers the soldier with the commander and keeps reporting that
+// it is alive, which missions it is running and how busy its workers are
+type Sender struct {
+	soldierID    string
+	unit         string
+	capabilities []string
+	hostname     string
+	interval     time.Duration
+	running      func() []string
+	load         func() (busy, size int)
+	publish      func(body []byte) error
+}
+
+// New returns a sender publishing to heartbeat_queue every interval.
+// running reports the IDs of the missions currently executing and load the
+// busy and total workers.
+func New(publisher broker.Broker, soldierID, unit string, capabilities []string, interval time.Duration, running func() []string, load func() (busy, size int)) *Sender {
+	hostname, _ := os.Hostname()
+	return &Sender{
+		soldierID:    soldierID,
+		unit:         unit,
+		capabilities: capabilities,
+		hostname:     hostname,
+		interval:     interval,
+		running:      running,
+		load:         load,
+		publish: func(body []byte) error {
+			return rabbitmq.PublishWithRetry(publisher, rabbitmq.HeartbeatQueue, body)
+		},
+	}
+}
+
+// Run registers the soldier, sends heartbeats until ctx is done and then
+// deregisters it
+func (s *Sender) Run(ctx context.Context) {
+	s.send(models.HeartbeatRegister)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.send(models.HeartbeatDeregister)
+			return
+		case <-ticker.C:
+			s.send(models.HeartbeatBeat)
+		}
+	}
+}
+
+// send publishes a single heartbeat message of the given type
+func (s *Sender) send(kind string) {
+	missions := s.running()
+	if missions == nil {
+		missions = []string{}
+	}
+	busy, size := s.load()
+	body, _ := json.Marshal(models.Heartbeat{
+		Type:         kind,
+		SoldierID:    s.soldierID,
+		Version:      models.Version,
+		Hostname:     s.hostname,
+		Unit:         s.unit,
+		Capabilities: s.capabilities,
+		Missions:     missions,
+		Workers:      size,
+		BusyWorkers:  busy,
+		SentAt:       time.Now().UTC(),
+	})
+	if err := s.publish(body); err != nil {
+		log.Printf("Failed to send %s: %v", kind, err)
+	}
+}
@@ -0,0 +1,48 @@
+package heartbeat
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"mission_control/soldier/models"
+)
+
+func TestSender_Run(t *testing.T) {
+	var mu sync.Mutex
+	var sent []models.Heartbeat
+	s := &Sender{
+		soldierID: "s1",
+		unit:      "alpha",
+		interval:  10 * time.Millisecond,
+		running:   func() []string { return []string{"m1"} },
+		load:      func() (int, int) { return 1, 4 },
+		publish: func(body []byte) error {
+			var hb models.Heartbeat
+			json.Unmarshal(body, &hb)
+			mu.Lock()
+			sent = append(sent, hb)
+			mu.Unlock()
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 35*time.Millisecond)
+	defer cancel()
+	s.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(sent) < 3 {
+		t.Fatalf("expected register, heartbeats and deregister, got %d messages", len(sent))
+	}
+	if sent[0].Type != models.HeartbeatRegister || sent[len(sent)-1].Type != models.HeartbeatDeregister {
+		t.Fatalf("expected register first and deregister last, got %s and %s", sent[0].Type, sent[len(sent)-1].Type)
+	}
+	beat := sent[1]
+	if beat.Type != models.HeartbeatBeat || beat.SoldierID != "s1" || beat.Unit != "alpha" || len(beat.Missions) != 1 || beat.BusyWorkers != 1 || beat.Workers != 4 {
+		t.Fatalf("unexpected heartbeat: %+v", beat)
+	}
+}
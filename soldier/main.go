@@ -3,24 +3,43 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
+	"mission_control/broker/amqpbroker"
 	"mission_control/soldier/auth"
+	"mission_control/soldier/config"
 	"mission_control/soldier/execute_mission"
+	"mission_control/soldier/heartbeat"
 	"mission_control/soldier/models"
 	"mission_control/soldier/rabbitmq"
 	"os"
 	"os/signal"
+	"syscall"
+	"time"
 )
 
+// requeueDelay is how long an order that could not be finished is held before it is requeued
+const requeueDelay = time.Second
+
 func main() {
 	log.Println("Soldier starting up...")
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	// SIGINT and SIGTERM stop new orders; running missions are drained first
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
-	//Connect to RabbitMQ with retry
-	conn, ch := rabbitmq.SetupRabbitWithRetry()
-	defer conn.Close()
-	defer ch.Close()
+
+	// Token rotation, control messages and heartbeats keep going until the drain is over
+	running, stopRunning := context.WithCancel(context.Background())
+	defer stopRunning()
+
+	//Connect to RabbitMQ, reconnecting with backoff whenever the broker goes away.
+	//Status updates and heartbeats count as sent once the broker confirmed them.
+	messages := amqpbroker.NewBroker(config.GetRabbitMQURL(), config.GetPublishConfirmTimeout())
+	defer messages.Close()
+	if err := messages.Declare(rabbitmq.Topology()); err != nil {
+		log.Fatalf("Failed to declare RabbitMQ topology: %v", err)
+	}
 
 	//Auth soldier with retry
 	if !auth.GetAuthWithRetry() {
@@ -28,48 +47,138 @@ func main() {
 	}
 
 	// Rotate token for every 30 seconds
-	go auth.RotateToken(ctx)
+	go auth.RotateToken(running)
 
-	//Start consuming messages
-	msgs, err := ch.Consume(rabbitmq.OrdersQueue, "", true, false, false, false, nil)
-	if err != nil {
-		log.Fatalf("Failed to register consumer: %v", err)
-	}
-	log.Println("Soldier waiting for missions...")
+	soldierID, unit, capabilities := config.GetSoldierID(), config.GetSoldierUnit(), config.GetSoldierCapabilities()
+
+	// Listen for control messages (e.g. cancel) from the commander
+	cancellations := execute_mission.NewCancellations()
+	controls := rabbitmq.ConsumeControl(running, messages, soldierID)
+	go func() {
+		for d := range controls {
+			var msg models.ControlMessage
+			if err := json.Unmarshal(d.Body, &msg); err != nil {
+				log.Printf("Invalid control message JSON: %s", err.Error())
+				continue
+			}
+			if msg.Type == models.ControlCancel {
+				log.Printf("Cancel received for mission %s", msg.MissionID)
+				cancellations.Cancel(msg.MissionID)
+			}
+		}
+	}()
+
+	//Register with the commander and keep sending heartbeats
+	// Missions run on a bounded pool of workers
+	workers := execute_mission.NewPool(config.GetWorkerPoolSize())
+	deregistered := make(chan struct{})
+	go func() {
+		defer close(deregistered)
+		heartbeat.New(messages, soldierID, unit, capabilities, config.GetHeartbeatInterval(), cancellations.Running, workers.Load).Run(running)
+	}()
+
+	//Start consuming shared orders and orders targeted at this soldier or its unit,
+	//taking no more orders than there are workers to run them
+	_, size := workers.Load()
+	msgs := rabbitmq.ConsumeOrders(ctx, messages, soldierID, unit, size)
+	log.Printf("Soldier %s (unit %q, capabilities %v, %d workers) waiting for missions...", soldierID, unit, capabilities, size)
 
-	//Process incoming missions
+	//Process incoming missions. Orders are acknowledged once their final status
+	//is published, so a soldier that dies mid-mission leaves them to be redelivered.
+	executions := execute_mission.NewExecutions()
 	for d := range msgs {
 		var mission models.Mission
 
-		// Validate incoming JSON
+		// Validate incoming JSON; invalid missions are dead-lettered
 		if err := json.Unmarshal(d.Body, &mission); err != nil {
 			log.Printf("Invalid mission JSON: %s", err.Error())
-			continue // Skip invalid mission
+			rabbitmq.DeadLetterOrder(messages, d, fmt.Errorf("invalid mission JSON: %w", err))
+			continue
 		}
 
 		if mission.ID == "" {
-			log.Println("Received mission with empty ID — skipping")
+			log.Println("Received mission with empty ID — dead-lettering")
+			rabbitmq.DeadLetterOrder(messages, d, errors.New("mission with empty ID"))
+			continue
+		}
+
+		// Redeliveries of an attempt running or finished here are not run twice
+		if !executions.Begin(mission) {
+			log.Printf("Mission %s attempt %d delivered again — skipping duplicate", mission.ID, mission.Attempt)
+			d.Ack(false)
 			continue
 		}
 
 		log.Printf("Mission received: %s", mission.ID)
 
-		// Execute mission safely
-		go func(m models.Mission) {
-			// Recover from panics inside mission execution
+		// The commander routes missions to capable soldiers; refuse any that slipped through
+		if missing := execute_mission.MissingCapabilities(mission, capabilities); len(missing) > 0 {
+			if err := execute_mission.RejectIncapable(mission, missing, messages); err != nil {
+				log.Printf("Mission %s could not be rejected: %v — requeueing", mission.ID, err)
+				executions.Abort(mission)
+				d.Nack(false, true)
+				continue
+			}
+			executions.Finish(mission)
+			d.Ack(false)
+			continue
+		}
+
+		missionCtx, done, ok := cancellations.Start(auth.Ctx, mission.ID)
+		if !ok {
+			log.Printf("Mission %s was cancelled before it started — skipping", mission.ID)
+			executions.Finish(mission)
+			d.Ack(false)
+			continue
+		}
+
+		// Execute mission safely on the next free worker
+		m := mission
+		workers.Go(func() {
+			defer done()
+			// Recover from panics inside mission execution; the order is dead-lettered
 			defer func() {
 				if r := recover(); r != nil {
 					log.Printf("Recovered from panic in mission %s: %v", m.ID, r)
+					executions.Finish(m)
+					rabbitmq.DeadLetterOrder(messages, d, fmt.Errorf("mission panicked: %v", r))
 				}
 			}()
 
-			execute_mission.ExecuteMission(auth.Ctx, m, ch)
-		}(mission)
+			if err := execute_mission.ExecuteMission(missionCtx, m, messages); err != nil {
+				log.Printf("Mission %s not finished: %v — requeueing", m.ID, err)
+				executions.Abort(m)
+				// Give a passing outage time to clear before the order comes back
+				time.Sleep(requeueDelay)
+				d.Nack(false, true)
+				return
+			}
+			executions.Finish(m)
+			d.Ack(false)
+		})
 	}
 
-	log.Println("RabbitMQ channel closed — soldier stopping")
+	// The orders stop once the shutdown signal arrives
+	drain(workers, cancellations, config.GetDrainTimeout())
+
+	// Deregister only now, so the commander does not give up on drained missions
+	stopRunning()
+	<-deregistered
+	log.Println("Soldier stopped")
+}
+
+// drain waits up to timeout for running missions to finish and publish their
+// final status. Missions still running then are interrupted and their orders
+// requeued for other soldiers.
+func drain(workers *execute_mission.Pool, cancellations *execute_mission.Cancellations, timeout time.Duration) {
+	log.Printf("Shutting down: waiting up to %v for %d running missions...", timeout, len(cancellations.Running()))
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if workers.Wait(ctx) == nil {
+		return
+	}
 
-	// Wait for interrupt signal
-	<-ctx.Done()
-	log.Println("Shutting down...")
+	log.Printf("Drain timeout reached — requeueing missions %v", cancellations.Running())
+	cancellations.CancelAll(execute_mission.ErrShuttingDown)
+	workers.Wait(context.Background())
 }
@@ -1,15 +1,105 @@
 package models
 
-// Mission represents a command sent to the soldier service
+import (
+	"encoding/json"
+	"time"
+)
+
+// Mission represents a command sent to the soldier service.
+// Typed orders carry the order type and its params, already validated
+// by the commander against the type's schema.
 type Mission struct {
-	ID     string `json:"mission_id"`
-	Order  string `json:"order"`
-	Status string `json:"status"`
+	ID       string          `json:"mission_id"`
+	Order    string          `json:"order"`
+	Status   string          `json:"status"`
+	Priority uint8           `json:"priority"`
+	Attempt  int             `json:"attempt"`
+	Type     string          `json:"type,omitempty"`
+	Params   json.RawMessage `json:"params,omitempty"`
+	// Capabilities the soldier needs to run the mission
+	Capabilities []string `json:"capabilities,omitempty"`
+}
+
+// StatusUpdate is the status message published to status_queue.
+// Seq increases with every update sent for a mission attempt so the
+// commander can discard late redeliveries of older updates; every execution
+// starts it from the clock. Attempt echoes
+// the attempt received with the mission. Final updates carry the mission
+// result or the error that made it fail.
+type StatusUpdate struct {
+	MissionID  string          `json:"mission_id"`
+	Status     string          `json:"status"`
+	Seq        int64           `json:"seq"`
+	Attempt    int             `json:"attempt,omitempty"`
+	Result     json.RawMessage `json:"result,omitempty"`
+	Error      *MissionError   `json:"error,omitempty"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Progress   *Progress       `json:"progress,omitempty"`
+}
+
+// StatusProgress marks updates that only report progress of a running
+// mission; they do not change its lifecycle status
+const StatusProgress = "PROGRESS"
+
+// Progress is how far a running mission has got
+type Progress struct {
+	Percent int    `json:"percent"`
+	Message string `json:"message,omitempty"`
+}
+
+// MissionError explains why a mission did not complete
+type MissionError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+// Error codes reported by soldiers
+const (
+	ErrorCodeFailed    = "MISSION_FAILED"
+	ErrorCodeCancelled = "MISSION_CANCELLED"
+	ErrorCodeIncapable = "MISSING_CAPABILITY"
+)
+
+// ControlCancel asks soldiers to skip or abort a mission
+const ControlCancel = "cancel"
+
+// ControlMessage is broadcast by the commander over the control exchange
+type ControlMessage struct {
+	Type      string `json:"type"`
+	MissionID string `json:"mission_id"`
+}
+
+// Version is the soldier version reported at registration; set at build time with
+// -ldflags "-X mission_control/soldier/models.Version=..."
+var Version = "dev"
+
+// Heartbeat message types published to heartbeat_queue
+const (
+	HeartbeatRegister   = "register"
+	HeartbeatBeat       = "heartbeat"
+	HeartbeatDeregister = "deregister"
+)
+
+// Heartbeat announces the soldier to the commander and reports its running
+// missions and how many of its workers are busy
+type Heartbeat struct {
+	Type      string `json:"type"`
+	SoldierID string `json:"soldier_id"`
+	Version   string `json:"version,omitempty"`
+	Hostname  string `json:"hostname,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	// Capabilities are the order capabilities the soldier can serve
+	Capabilities []string  `json:"capabilities,omitempty"`
+	Missions     []string  `json:"missions"`
+	Workers      int       `json:"workers"`
+	BusyWorkers  int       `json:"busy_workers"`
+	SentAt       time.Time `json:"sent_at"`
 }
 
 // Token holds the access and refresh tokens received from authentication
 type Token struct {
-	AccessToken    string `json:"access_token"`
+	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
 
@@ -17,4 +107,3 @@ type Token struct {
 type LoginResponse struct {
 	Token Token `json:"token"`
 }
-
@@ -0,0 +1,68 @@
+package rabbitmq
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"mission_control/broker"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestConsumeOrders_MemoryBroker(t *testing.T) {
+	b := broker.NewMemory()
+	if err := b.Declare(Topology()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	msgs := ConsumeOrders(ctx, b, "s1", "alpha", 1)
+
+	for _, order := range []struct{ exchange, key string }{
+		{"", OrdersQueue},
+		{OrdersExchange, SoldierRoutingPrefix + "s1"},
+		{OrdersExchange, UnitRoutingPrefix + "alpha"},
+	} {
+		if err := b.Publish(order.exchange, order.key, true, amqp.Publishing{Body: []byte(order.key)}); err != nil {
+			t.Fatalf("publishing to %s: unexpected error: %v", order.key, err)
+		}
+	}
+	if err := b.Publish(OrdersExchange, UnitRoutingPrefix+"bravo", true, amqp.Publishing{}); !errors.Is(err, broker.ErrUnroutable) {
+		t.Fatalf("expected orders for another unit to be unroutable, got %v", err)
+	}
+
+	// With a prefetch of 1 each order arrives only after the previous one is acknowledged
+	received := map[string]bool{}
+	for range 3 {
+		select {
+		case d := <-msgs:
+			received[string(d.Body)] = true
+			select {
+			case extra := <-msgs:
+				t.Fatalf("expected no order before the ack, got %s", extra.Body)
+			case <-time.After(20 * time.Millisecond):
+			}
+			d.Ack(false)
+		case <-time.After(time.Second):
+			t.Fatalf("expected 3 orders, got %v", received)
+		}
+	}
+	if len(received) != 3 {
+		t.Fatalf("expected an order from every queue, got %v", received)
+	}
+}
+
+func TestPublishWithRetry_Persistent(t *testing.T) {
+	b := broker.NewMemory()
+	b.Declare(Topology())
+
+	if err := PublishWithRetry(b, StatusQueue, []byte(`{"mission_id":"m1"}`)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	d, ok := b.Get(StatusQueue)
+	if !ok || d.DeliveryMode != amqp.Persistent {
+		t.Fatalf("expected a persistent status update, got %+v", d)
+	}
+}